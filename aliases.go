@@ -0,0 +1,106 @@
+package caller
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// collectAliased returns top-level struct fields of the argument type that
+// are tagged with `aliases:"oldName,olderName"`.
+func collectAliased(typ reflect.Type) []reflect.StructField {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var res []reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath == "" && f.Tag.Get("aliases") != "" {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// resolveAliases renames keys of a JSON object that match field aliases to
+// their primary field names. Aliases are tried in order and only when the
+// primary name is absent from the object. Data that is not an object, or has
+// no aliased keys, is returned as is.
+func resolveAliases(data []byte, fields []reflect.StructField, key string) []byte {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return data
+	}
+
+	var changed bool
+	for _, f := range fields {
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := lookupField(obj, name); ok {
+			continue
+		}
+		for _, alias := range strings.Split(f.Tag.Get("aliases"), ",") {
+			alias = strings.TrimSpace(alias)
+			if alias == "" {
+				continue
+			}
+			if raw, ok := lookupField(obj, alias); ok {
+				obj[name] = raw
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return data
+	}
+	if res, err := json.Marshal(obj); err == nil {
+		return res
+	}
+	return data
+}
+
+// resolveMapAliases works like resolveAliases for a map parsed from JSON. The
+// map is copied before it is modified.
+func resolveMapAliases(m map[string]interface{}, fields []reflect.StructField, key string) map[string]interface{} {
+	res, copied := m, false
+	for _, f := range fields {
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := lookupMapField(res, name); ok {
+			continue
+		}
+		for _, alias := range strings.Split(f.Tag.Get("aliases"), ",") {
+			alias = strings.TrimSpace(alias)
+			if alias == "" {
+				continue
+			}
+			if v, ok := lookupMapField(res, alias); ok {
+				if !copied {
+					res = make(map[string]interface{}, len(m)+1)
+					for k, v := range m {
+						res[k] = v
+					}
+					copied = true
+				}
+				res[name] = v
+				break
+			}
+		}
+	}
+	return res
+}
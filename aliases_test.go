@@ -0,0 +1,73 @@
+package caller
+
+import (
+	"testing"
+)
+
+type testAliasedMessage struct {
+	Title string `json:"title" aliases:"name,subject"`
+}
+
+func TestCallWithAlias(t *testing.T) {
+	var got testAliasedMessage
+	c, _ := New(func(m testAliasedMessage) { got = m })
+	c.ResolveAliases = true
+
+	if err := c.Call([]byte(`{"name":"Success!"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Title != "Success!" {
+		t.Errorf("Expected title to be %q, got %q", "Success!", got.Title)
+	}
+}
+
+func TestCallWithAliasOrder(t *testing.T) {
+	var got testAliasedMessage
+	c, _ := New(func(m testAliasedMessage) { got = m })
+	c.ResolveAliases = true
+
+	if err := c.Call([]byte(`{"subject":"older","name":"old"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Title != "old" {
+		t.Errorf("Expected the first alias to win, got %q", got.Title)
+	}
+}
+
+func TestCallWithAliasPrimaryPresent(t *testing.T) {
+	var got testAliasedMessage
+	c, _ := New(func(m testAliasedMessage) { got = m })
+	c.ResolveAliases = true
+
+	if err := c.Call([]byte(`{"title":"new","name":"old"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Title != "new" {
+		t.Errorf("Expected the primary name to win, got %q", got.Title)
+	}
+}
+
+func TestCallWithAliasRequired(t *testing.T) {
+	type msg struct {
+		Title string `json:"title" aliases:"name" required:"true"`
+	}
+	c, _ := New(func(m msg) {})
+	c.ResolveAliases = true
+	c.EnforceRequired = true
+
+	if err := c.Call([]byte(`{"name":"old"}`)); err != nil {
+		t.Errorf("Expected an alias to satisfy a required field, got %v", err)
+	}
+}
+
+func TestCallWithAliasDisabled(t *testing.T) {
+	var got testAliasedMessage
+	c, _ := New(func(m testAliasedMessage) { got = m })
+
+	if err := c.Call([]byte(`{"name":"old"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Title != "" {
+		t.Errorf("Expected aliases to be ignored by default, got %q", got.Title)
+	}
+}
@@ -0,0 +1,43 @@
+package caller
+
+import (
+	"reflect"
+)
+
+// collectAllocFields returns indices of top-level pointer fields of the
+// argument type that are tagged with `alloc:"true"`.
+func collectAllocFields(typ reflect.Type) [][]int {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var res [][]int
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath == "" && f.Type.Kind() == reflect.Ptr && f.Tag.Get("alloc") == "true" {
+			res = append(res, f.Index)
+		}
+	}
+	return res
+}
+
+// allocFields allocates pointees of nil pointer fields, so that the payload
+// is decoded into them and they stay non-nil when absent from it. Nil
+// pointers leading to the struct are allocated as well.
+func allocFields(val reflect.Value, fields [][]int) {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+	for _, idx := range fields {
+		fval := val.FieldByIndex(idx)
+		if fval.IsNil() {
+			fval.Set(reflect.New(fval.Type().Elem()))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package caller
+
+import (
+	"testing"
+)
+
+type testAllocConfig struct {
+	Retries int    `json:"retries"`
+	Mode    string `json:"mode"`
+}
+
+type testAllocMessage struct {
+	Body   string           `json:"body"`
+	Config *testAllocConfig `json:"config" alloc:"true"`
+	Extra  *testAllocConfig `json:"extra"`
+}
+
+func TestCallAllocPointers(t *testing.T) {
+	var got testAllocMessage
+	c, _ := New(func(m testAllocMessage) { got = m })
+	c.AllocPointers = true
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Config == nil {
+		t.Fatal("Expected config to be allocated")
+	}
+	if *got.Config != (testAllocConfig{}) {
+		t.Errorf("Expected config to have zero values, got %+v", *got.Config)
+	}
+	if got.Extra != nil {
+		t.Errorf("Expected untagged pointer to stay nil, got %+v", got.Extra)
+	}
+}
+
+func TestCallAllocPointersTemplateDefaults(t *testing.T) {
+	var got testAllocMessage
+	c, _ := New(func(m testAllocMessage) { got = m })
+	c.AllocPointers = true
+	c.Template = testAllocMessage{Config: &testAllocConfig{Retries: 3, Mode: "fast"}}
+	c.DeepCopyTemplate = true
+
+	if err := c.Call([]byte(`{"config":{"mode":"slow"}}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	exp := testAllocConfig{Retries: 3, Mode: "slow"}
+	if got.Config == nil || *got.Config != exp {
+		t.Errorf("Expected config to be %+v, got %+v", exp, got.Config)
+	}
+}
+
+func TestCallAllocPointersPointerArg(t *testing.T) {
+	var got *testAllocMessage
+	c, _ := New(func(m *testAllocMessage) { got = m })
+	c.AllocPointers = true
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got == nil || got.Config == nil {
+		t.Fatalf("Expected config to be allocated, got %+v", got)
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallAllocPointersExplicitNull(t *testing.T) {
+	var got testAllocMessage
+	c, _ := New(func(m testAllocMessage) { got = m })
+	c.AllocPointers = true
+
+	if err := c.Call([]byte(`{"config":null}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Config == nil {
+		t.Error("Expected config set to null to be allocated")
+	}
+}
+
+func TestCallAllocPointersDisabled(t *testing.T) {
+	var got testAllocMessage
+	c, _ := New(func(m testAllocMessage) { got = m })
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Config != nil {
+		t.Errorf("Expected config to stay nil, got %+v", got.Config)
+	}
+}
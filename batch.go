@@ -0,0 +1,60 @@
+package caller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotArray is an error that is returned by CallBatchResult when the
+// payload is null rather than a JSON array.
+var ErrNotArray = errors.New("payload is not an array")
+
+// BatchItemResult is the outcome of calling the Caller function with a single
+// element of a batch.
+type BatchItemResult struct {
+	Index int
+	Err   error
+}
+
+// BatchElementError is an error that describes a failure to process a single
+// batch element. It carries the element's original bytes, which allows
+// dead-lettering just the offending element.
+type BatchElementError struct {
+	Index int
+	Err   error
+	Raw   json.RawMessage
+}
+
+// Error returns the element index along with the underlying error message.
+func (e *BatchElementError) Error() string {
+	return fmt.Sprintf("batch element %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BatchElementError) Unwrap() error {
+	return e.Err
+}
+
+// CallBatchResult calls the Caller function once for every element of a JSON
+// array and reports the outcome of each call. The returned error is only set
+// when the payload is not a JSON array, element failures are reported in
+// the results as *BatchElementError.
+func (c *Caller) CallBatchResult(data []byte) ([]BatchItemResult, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, ErrNotArray
+	}
+
+	res := make([]BatchItemResult, len(items))
+	for i, item := range items {
+		res[i] = BatchItemResult{Index: i}
+		if err := c.Call(item); err != nil {
+			res[i].Err = &BatchElementError{Index: i, Err: err, Raw: item}
+		}
+	}
+	return res, nil
+}
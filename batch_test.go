@@ -0,0 +1,44 @@
+package caller
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCallBatchResult(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	res, err := c.CallBatchResult([]byte(`[{"body":"a"},{"body":1},{"body":"c"}]`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 3 {
+		t.Fatalf("Expected three results, got %d", len(res))
+	}
+	for i, r := range res {
+		if r.Index != i {
+			t.Errorf("Expected result %d to have index %d, got %d", i, i, r.Index)
+		}
+		if failed := r.Err != nil; failed != (i == 1) {
+			t.Errorf("Unexpected error for element %d: %v", i, r.Err)
+		}
+	}
+
+	var elemErr *BatchElementError
+	if !errors.As(res[1].Err, &elemErr) {
+		t.Fatalf("Expected BatchElementError, got: %v", res[1].Err)
+	}
+	if elemErr.Index != 1 || string(elemErr.Raw) != `{"body":1}` {
+		t.Errorf("Expected error for element 1 with raw %q, got %d and %q", `{"body":1}`, elemErr.Index, elemErr.Raw)
+	}
+
+	if _, err := c.CallBatchResult([]byte(testPayload)); err == nil {
+		t.Error("Expected an error for a payload that is not an array, got nil")
+	}
+	if res, err := c.CallBatchResult([]byte(" null ")); err != ErrNotArray {
+		t.Errorf("Expected ErrNotArray for null, got %v with %v", err, res)
+	}
+	if res, err := c.CallBatchResult([]byte("[]")); err != nil || len(res) != 0 {
+		t.Errorf("Expected no results for an empty array, got %v with %v", err, res)
+	}
+}
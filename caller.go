@@ -5,17 +5,160 @@
 package caller
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"time"
 )
 
 // Caller wraps a function and makes it ready to be dynamically called.
 type Caller struct {
-	// Unmarshaller is a BYOB unmarshaller function. By default it uses JSON.
+	// Unmarshaller is a BYOB unmarshaller function. By default it uses JSON,
+	// unless DefaultUnmarshaller is set.
 	Unmarshaller func(data []byte, v interface{}) error
-	fun          reflect.Value
-	argtyp       reflect.Type
+	// TagKey is an optional struct tag key to read JSON field names from
+	// instead of `json`. When set, payloads are decoded as JSON regardless of
+	// the Unmarshaller.
+	TagKey string
+	// NewValue is an optional function that creates the decode target instead
+	// of the default allocation, which gives full control over initializing
+	// it. It must return a pointer to the argument type.
+	NewValue func() interface{}
+	// Template is an optional value of the argument type that is copied into
+	// the decode target before unmarshalling, so that fields missing from the
+	// payload keep the template's values. Unless DeepCopyTemplate is set,
+	// only top-level pointer, map and slice fields are copied, one level
+	// deep. Values nested deeper are shared with the template, so payloads
+	// that reach them modify the template and concurrent calls race on it;
+	// DeepCopyTemplate must be set for such templates.
+	Template interface{}
+	// DeepCopyTemplate makes Call copy the Template recursively.
+	DeepCopyTemplate bool
+	// AllocPointers makes Call allocate nil pointer fields tagged with
+	// `alloc:"true"` before unmarshalling, so that the function never
+	// receives them as nil. Fields that are absent from the payload keep
+	// their zero pointees, and fields set to null are allocated again after
+	// unmarshalling.
+	AllocPointers bool
+	// ResolveAliases makes Call honor `aliases:"oldName,olderName"` tags on
+	// top-level fields of the argument type: when a field's name is absent
+	// from the payload, the first alias present is used instead. The payload
+	// has to be parsed and encoded again for that, and only JSON objects are
+	// rewritten, so it has no effect with unmarshallers of other formats.
+	ResolveAliases bool
+	// EnforceRequired makes Call fail with ErrMissingRequiredField when a
+	// field of the argument type tagged with `required:"true"` is absent from
+	// the JSON payload. Unlike checking for zero values, a present zero value
+	// passes.
+	EnforceRequired bool
+	// EpochUnit makes Call interpret JSON numbers in top-level time.Time
+	// fields of the argument type as the number of given units elapsed since
+	// the Unix epoch, typically time.Second or time.Millisecond. Zero means
+	// times must be RFC 3339 strings, as usual.
+	EpochUnit time.Duration
+	// RejectDuplicateKeys makes Call scan JSON payloads for objects with
+	// duplicate keys and fail with ErrDuplicateKey instead of silently using
+	// the last value. Keys of objects decoded into structs are compared
+	// case-insensitively, the way encoding/json matches them to fields, and
+	// keys of maps are compared exactly. It should be enabled for untrusted
+	// input.
+	RejectDuplicateKeys bool
+	// MaxDepth limits how deeply objects and arrays can be nested in JSON
+	// payloads. Payloads exceeding it are rejected with ErrMaxDepthExceeded
+	// before being unmarshalled, which protects functions from adversarial
+	// input. Zero means no limit.
+	MaxDepth int
+	// ObserveSize is an optional hook that is invoked with the size of every
+	// payload before it is unmarshalled, which allows recording payload size
+	// metrics.
+	ObserveSize func(bytes int)
+	// AfterDispatch is an optional callback that is invoked after the function
+	// returns. It receives the decoded value and the error produced by the
+	// call, which makes it a good place to release resources tied to the
+	// value.
+	AfterDispatch func(v interface{}, err error)
+	// ErrorWrapper is an optional function that errors returned by Call are
+	// passed through. It receives the original error and the payload, which
+	// allows adding context to it. By default errors are returned as is.
+	ErrorWrapper func(err error, data []byte) error
+	// AnnotateType makes Call prefix errors returned by the function with the
+	// name of its argument type, which helps telling where an error came from
+	// in logs. Annotation happens before passing the error to ErrorWrapper.
+	AnnotateType bool
+	// ContinueOnError makes CallChan keep consuming payloads after a call
+	// fails. The first error is then returned once the channel is closed.
+	ContinueOnError bool
+	// IdempotencyKey is an optional function that extracts an idempotency key
+	// from the decoded value. When both it and Seen are set, Call consults
+	// Seen before calling the function and skips duplicate payloads. Values
+	// with an empty key are never skipped. See Seen for what happens to
+	// payloads the function fails on.
+	IdempotencyKey func(v interface{}) string
+	// Seen keeps track of processed idempotency keys.
+	Seen Seen
+	// SkipTypeMismatch makes Call skip payloads that do not match the
+	// function's argument type, which are reported by encoding/json as
+	// json.UnmarshalTypeError, and return nil instead of an error. This lets
+	// a Caller tolerate foreign messages in a shared stream.
+	SkipTypeMismatch bool
+	// IncludePayloadInError makes Call return errors of type *PayloadError
+	// that carry the payload, truncated to MaxErrorPayload bytes. It is off
+	// by default so that payload contents do not leak into logs.
+	IncludePayloadInError bool
+	// MaxErrorPayload limits the number of payload bytes attached to errors.
+	// Zero means the default limit of 256 bytes.
+	MaxErrorPayload int
+	// Fallback is an optional Caller that the payload is delegated to when it
+	// can not be unmarshalled into this Caller's argument type, which enables
+	// handling of multiple message versions. Metadata given to CallWithMeta
+	// and the CallEmit sink are delegated too, so the fallback function must
+	// accept them, otherwise ErrInvalidMeta is returned. Listeners get a nil
+	// value and the error returned by the fallback.
+	Fallback *Caller
+	// DefaultTimeout makes Call fail with ErrCallTimeout when the function
+	// does not return within the given duration. The function is not
+	// interrupted, it keeps running in its own goroutine until it returns,
+	// but it can observe the timeout if it accepts a context. Zero means no
+	// timeout.
+	DefaultTimeout time.Duration
+	// OnEmptyObject is an optional hook that is invoked when the payload is an
+	// empty JSON object, right before the function is called. An empty object
+	// decodes into the zero value of the argument type, and the function is
+	// called with it as usual.
+	OnEmptyObject func()
+	// Listeners are invoked after every call with the decoded value and the
+	// resulting error. The value is nil when the payload fails to decode.
+	// Listeners run synchronously and should not block.
+	Listeners []func(v interface{}, err error)
+	// DryRun makes Call unmarshal the payload and return any error without
+	// calling the function, which is useful for validating payloads against
+	// the function's argument type without side effects.
+	DryRun bool
+
+	fun     reflect.Value
+	argtyp  reflect.Type
+	withCtx bool
+	withErr bool
+	// metatyp is the type of an optional argument that follows the one data
+	// is unmarshalled into. It is passed by CallWithMeta as is.
+	metatyp  reflect.Type
+	zeroMeta reflect.Value
+	withRaw  bool
+	withEmit bool
+	variadic bool
+
+	transforms []fieldTransform
+	required   []requiredField
+	token      []int
+	times      []reflect.StructField
+	alloc      [][]int
+	aliased    []reflect.StructField
+	inKinds    []reflect.Kind
 }
 
 var (
@@ -24,33 +167,155 @@ var (
 	ErrInvalidFunctionType = errors.New("argument must be function")
 	// ErrInvalidFunctionInArguments is an error that is returned by the New
 	// function when its argument-function has a number of input arguments other
-	// than 1.
-	ErrInvalidFunctionInArguments = errors.New("function must have only one input argument")
+	// than 1, not counting an optional leading context.Context and an optional
+	// trailing metadata struct.
+	ErrInvalidFunctionInArguments = errors.New("function must have only one input argument, optionally preceded by a context and followed by metadata")
 	// ErrInvalidFunctionOutArguments is an error that is returned by the New
-	// function when its argument-function returs any values.
-	ErrInvalidFunctionOutArguments = errors.New("function must not have output arguments")
+	// function when its argument-function returns any values other than a
+	// single error.
+	ErrInvalidFunctionOutArguments = errors.New("function must not have output arguments other than an error")
+	// ErrUnsupportedArgType is an error that is returned by the New function
+	// when its argument-function accepts a value of a type that data can not
+	// be unmarshalled into, like a channel, a function or a complex number.
+	ErrUnsupportedArgType = errors.New("function argument type is not supported")
+	// ErrInvalidMeta is an error that is returned by CallWithMeta when the
+	// metadata value can not be passed to the Caller function or its Fallback.
+	ErrInvalidMeta = errors.New("metadata does not match function argument type")
+	// ErrInvalidValue is an error that is returned by Invoke, CallValueAll and
+	// Call, when NewValue is used, if the value does not match the Caller
+	// function's argument type.
+	ErrInvalidValue = errors.New("value does not match function argument type")
+	// ErrTruncatedFrame is an error that is returned by CallFramed when the
+	// stream ends in the middle of a frame.
+	ErrTruncatedFrame = errors.New("truncated frame")
+	// ErrCallTimeout is an error that is returned by Call when DefaultTimeout
+	// is set and the function did not return in time.
+	ErrCallTimeout = errors.New("function call timed out")
+)
+
+// DefaultUnmarshaller is the unmarshaller function that New assigns to
+// Callers it creates. When it is nil, json.Unmarshal is used. It makes it
+// possible to switch every Caller in a process to a different format with a
+// single assignment at init time.
+var DefaultUnmarshaller func(data []byte, v interface{}) error
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	bytesType   = reflect.TypeOf([]byte(nil))
 )
 
+// decodableKinds lists kinds of values that data can be unmarshalled into.
+var decodableKinds = map[reflect.Kind]bool{
+	reflect.Bool:      true,
+	reflect.Int:       true,
+	reflect.Int8:      true,
+	reflect.Int16:     true,
+	reflect.Int32:     true,
+	reflect.Int64:     true,
+	reflect.Uint:      true,
+	reflect.Uint8:     true,
+	reflect.Uint16:    true,
+	reflect.Uint32:    true,
+	reflect.Uint64:    true,
+	reflect.Uintptr:   true,
+	reflect.Float32:   true,
+	reflect.Float64:   true,
+	reflect.String:    true,
+	reflect.Array:     true,
+	reflect.Slice:     true,
+	reflect.Map:       true,
+	reflect.Struct:    true,
+	reflect.Interface: true,
+}
+
 // New creates a new Caller instance using the function given as an argument.
 // It returns the Caller instance and an error if something is wrong with the
-// argument-function.
+// argument-function. The function may accept a context.Context as its first
+// argument, in which case the data is unmarshalled into the second one. The
+// function may return an error, which is then returned by Call. A second
+// argument of a struct type, or a pointer to one, receives metadata passed to
+// CallWithMeta as is, while a second argument of type []byte receives the
+// raw payload, or nil when the function is called with an already decoded
+// value by Invoke, CallValueAll or CallMap. A second argument of type func(Event) is an emit function, see
+// CallEmit. A variadic function receives the elements of a
+// decoded array as separate arguments.
+//
+// The argument type should be concrete for custom unmarshalling to work:
+// a value of that type is allocated for every call, so its UnmarshalJSON
+// method (or the equivalent of the configured Unmarshaller) is invoked. An
+// interface argument type would only receive generic values.
+//
+// String fields of the argument type can be normalized after unmarshalling
+// with tags like `transform:"trim,lower"`, see RegisterTransform. Unexported
+// fields are ignored by transforms and any other reflection based pass.
 func New(fun interface{}) (c *Caller, err error) {
 	fval := reflect.ValueOf(fun)
 	ftyp := reflect.TypeOf(fun)
 	if ftyp.Kind() != reflect.Func {
 		return nil, ErrInvalidFunctionType
 	}
-	if ftyp.NumIn() != 1 {
+	withCtx := ftyp.NumIn() > 1 && ftyp.In(0) == contextType
+	first := 0
+	if withCtx {
+		first = 1
+	}
+	var metatyp reflect.Type
+	switch ftyp.NumIn() - first {
+	case 1:
+	case 2:
+		metatyp = ftyp.In(first + 1)
+		if !isMetaType(metatyp) {
+			return nil, ErrInvalidFunctionInArguments
+		}
+	default:
 		return nil, ErrInvalidFunctionInArguments
 	}
-	if ftyp.NumOut() != 0 {
+	withErr := ftyp.NumOut() == 1 && ftyp.Out(0) == errorType
+	if ftyp.NumOut() != 0 && !withErr {
 		return nil, ErrInvalidFunctionOutArguments
 	}
 
+	argtyp := ftyp.In(first)
+	if err := checkArgType(argtyp); err != nil {
+		return nil, err
+	}
+	info := newTypeInfo(argtyp)
+	if info.err != nil {
+		return nil, info.err
+	}
+
+	unmarshaller := DefaultUnmarshaller
+	if unmarshaller == nil {
+		unmarshaller = json.Unmarshal
+	}
+
 	c = &Caller{
-		Unmarshaller: json.Unmarshal,
+		Unmarshaller: unmarshaller,
 		fun:          fval,
-		argtyp:       ftyp.In(0),
+		argtyp:       argtyp,
+		withCtx:      withCtx,
+		withErr:      withErr,
+		metatyp:      metatyp,
+		withRaw:      metatyp == bytesType,
+		withEmit:     metatyp != nil && isEmitType(metatyp),
+		variadic:     ftyp.IsVariadic(),
+		transforms:   info.transforms,
+		required:     info.required,
+		token:        info.token,
+		times:        info.times,
+		alloc:        info.alloc,
+		aliased:      info.aliased,
+		inKinds:      make([]reflect.Kind, ftyp.NumIn()),
+	}
+	for i := range c.inKinds {
+		c.inKinds[i] = ftyp.In(i).Kind()
+	}
+	switch {
+	case c.withEmit:
+		c.zeroMeta = noopEmit(metatyp)
+	case metatyp != nil:
+		c.zeroMeta = reflect.Zero(metatyp)
 	}
 
 	return c, nil
@@ -58,25 +323,561 @@ func New(fun interface{}) (c *Caller, err error) {
 
 // Call creates an instance of the Caller function's argument type, unmarshalls
 // the payload into it and dynamically calls the Caller function with this
-// instance.
+// instance. It returns an unmarshalling error or the error returned by the
+// function.
 func (c *Caller) Call(data []byte) error {
+	if c.DefaultTimeout > 0 {
+		err := c.CallContextTimeout(context.Background(), data, c.DefaultTimeout)
+		if err == context.DeadlineExceeded {
+			return c.wrapError(ErrCallTimeout, data)
+		}
+		return err
+	}
+	return c.call(context.Background(), data)
+}
+
+// CallString is like Call but accepts the payload as a string.
+func (c *Caller) CallString(data string) error {
+	return c.Call([]byte(data))
+}
+
+// MustNew is like New but panics if the Caller can not be created. It
+// simplifies initialization of Callers in package-level variables.
+func MustNew(fun interface{}) *Caller {
+	c, err := New(fun)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// WithUnmarshaller sets the Caller's Unmarshaller and returns the Caller,
+// which allows configuring it inline.
+func (c *Caller) WithUnmarshaller(fn func(data []byte, v interface{}) error) *Caller {
+	c.Unmarshaller = fn
+	return c
+}
+
+// InputKinds returns the kinds of the Caller function's input arguments. For
+// a function accepting a context the first kind is reflect.Interface.
+func (c *Caller) InputKinds() []reflect.Kind {
+	return append([]reflect.Kind(nil), c.inKinds...)
+}
+
+// Unmarshal is the first step of Call. It creates an instance of the Caller
+// function's argument type and unmarshalls the payload into it. The returned
+// value is a pointer to the instance and can be modified before passing it to
+// Invoke.
+func (c *Caller) Unmarshal(data []byte) (reflect.Value, error) {
+	return c.unmarshal(data)
+}
+
+// Invoke is the second step of Call. It dynamically calls the Caller function
+// with a value returned by Unmarshal and returns the function's error. It
+// returns ErrInvalidValue if the value is not a pointer to the argument type.
+// Functions accepting the raw payload receive nil, as Invoke has no payload.
+func (c *Caller) Invoke(v reflect.Value) error {
+	if !v.IsValid() || v.Type() != reflect.PtrTo(c.argtyp) {
+		return ErrInvalidValue
+	}
+	return c.invoke(context.Background(), v, c.zeroMeta)
+}
+
+// Valid reports whether the payload can be unmarshalled into the Caller
+// function's argument type by running the same decoding steps as Call, but
+// without calling the function. Fallback Callers are consulted as well.
+func (c *Caller) Valid(data []byte) error {
+	_, err := c.unmarshal(data)
+	if err != nil && c.Fallback != nil {
+		return c.Fallback.Valid(data)
+	}
+	return c.wrapError(err, data)
+}
+
+// CallValueAll calls every Caller's function with the already decoded value,
+// which saves decoding the same payload for each of them. All Callers must
+// accept the value's type, otherwise ErrInvalidValue is reported for them.
+// Every function is called even if some fail, and their errors are joined.
+// Functions accepting the raw payload receive nil, as there is none.
+func CallValueAll(v interface{}, callers ...*Caller) error {
+	val := reflect.ValueOf(v)
+
+	var errs []error
+	for _, c := range callers {
+		if !val.IsValid() || val.Type() != c.argtyp {
+			errs = append(errs, ErrInvalidValue)
+			continue
+		}
+		ptr := reflect.New(c.argtyp)
+		ptr.Elem().Set(val)
+		if err := c.invoke(context.Background(), ptr, c.zeroMeta); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CallTimed decodes the payload and calls the Caller function just like
+// Unmarshal followed by Invoke does, and reports how long each of the two
+// phases took. It is meant for ad-hoc profiling, the handle duration is zero
+// if decoding fails.
+func (c *Caller) CallTimed(data []byte) (decode, handle time.Duration, err error) {
+	start := time.Now()
 	val, err := c.unmarshal(data)
+	decode = time.Since(start)
 	if err != nil {
+		return decode, 0, c.wrapError(err, data)
+	}
+
+	start = time.Now()
+	err = c.invoke(context.Background(), val, c.defaultMeta(data))
+	handle = time.Since(start)
+	return decode, handle, c.wrapError(err, data)
+}
+
+// Matches reports whether the Caller function has the same type as the given
+// prototype function, which lets hosts verify that dynamically loaded
+// functions implement the expected contract.
+func (c *Caller) Matches(proto interface{}) bool {
+	return c.fun.Type() == reflect.TypeOf(proto)
+}
+
+// CallContext works like Call but passes the given context to the Caller
+// function if it accepts a context.Context as its first argument.
+func (c *Caller) CallContext(ctx context.Context, data []byte) error {
+	return c.call(ctx, data)
+}
+
+// CallWithValue works like CallContext but first attaches the value to the
+// context under the given key. It is a shorthand for carrying request-scoped
+// data like correlation IDs to the Caller function.
+func (c *Caller) CallWithValue(ctx context.Context, data []byte, key, val interface{}) error {
+	return c.call(context.WithValue(ctx, key, val), data)
+}
+
+// CallContextTimeout works like Call but derives a child context of ctx that
+// is cancelled after the given duration and passes it to the Caller function,
+// which must accept a context.Context as its first argument to observe it.
+// It returns when either the function returns or the deadline fires, in which
+// case the context error is returned. The function is responsible for
+// honoring the context, it is not interrupted and keeps running in its own
+// goroutine until it returns.
+func (c *Caller) CallContextTimeout(ctx context.Context, data []byte, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.call(ctx, data)
+	}()
+
+	select {
+	case err := <-done:
 		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CallConcat calls the Caller function once for every JSON value in a
+// payload made of concatenated values with no array wrapper, like `{}{}{}`.
+// Each value is then unmarshalled using the Caller's Unmarshaller. It stops
+// and returns the first error encountered.
+func (c *Caller) CallConcat(data []byte) error {
+	return c.CallStreamContext(context.Background(), bytes.NewReader(data))
+}
+
+// CallStreamContext calls the Caller function once for every JSON value read
+// from the stream, passing the context to it. The context is checked between
+// values and its error is returned once it is cancelled, which allows
+// bounding the processing time of huge streams. It stops and returns the
+// first error encountered.
+func (c *Caller) CallStreamContext(ctx context.Context, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := c.call(ctx, raw); err != nil {
+			return err
+		}
+	}
+}
+
+// CallFramed reads length-prefixed frames from the reader and calls the
+// Caller function with each frame's payload. Every frame starts with its
+// payload length as a big-endian uint32. It returns nil once the stream ends
+// on a frame boundary, ErrTruncatedFrame if it ends in the middle of a frame,
+// and stops on the first error otherwise.
+func (c *Caller) CallFramed(r io.Reader) error {
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err == io.EOF {
+			return nil
+		} else if err == io.ErrUnexpectedEOF {
+			return ErrTruncatedFrame
+		} else if err != nil {
+			return err
+		}
+
+		// Copying instead of allocating the whole frame upfront keeps a bogus
+		// length from reserving memory for data that never arrives
+		size := int64(binary.BigEndian.Uint32(header[:]))
+		var buf bytes.Buffer
+		if n, err := io.CopyN(&buf, r, size); n < size {
+			if err == io.EOF {
+				return ErrTruncatedFrame
+			}
+			return err
+		}
+		if err := c.Call(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// CallMap works like Call but populates the Caller function's argument from
+// a map that was already parsed from JSON, which avoids marshalling it back
+// only to unmarshal it again. Field names are read from `json` struct tags,
+// or from TagKey if it is set. Functions accepting the raw payload receive
+// nil, as there is none.
+//
+// NewValue, Template, AllocPointers, ResolveAliases, EnforceRequired, DryRun,
+// idempotency keys, OnEmptyObject, cancel tokens, Listeners and error
+// handling options are honored just like by Call. Options that work on the
+// encoded payload are not: Unmarshaller, RejectDuplicateKeys, MaxDepth,
+// EpochUnit, ObserveSize, SkipTypeMismatch, Fallback and DefaultTimeout.
+func (c *Caller) CallMap(m map[string]interface{}) error {
+	val, err := c.decodeMap(m)
+	if err != nil {
+		c.notify(nil, err)
+		return c.wrapError(err, nil)
+	}
+	return c.dispatch(context.Background(), val, c.zeroMeta, nil, len(m) == 0)
+}
+
+// CallChan calls the Caller function with every payload received from the
+// channel until it is closed or the context is cancelled, in which case the
+// context error is returned. The context is also passed to the function. It
+// stops on the first error unless ContinueOnError is set.
+func (c *Caller) CallChan(ctx context.Context, ch <-chan []byte) error {
+	var firstErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data, ok := <-ch:
+			if !ok {
+				return firstErr
+			}
+			err := c.call(ctx, data)
+			if err == nil {
+				continue
+			}
+			if !c.ContinueOnError {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
 	}
+}
+
+// CallWithMeta works like Call but also passes meta, which is not
+// unmarshalled, as the Caller function's second argument. It returns
+// ErrInvalidMeta if the function does not accept metadata of that type. A nil
+// meta is passed as a zero value.
+func (c *Caller) CallWithMeta(data []byte, meta interface{}) error {
+	if c.metatyp == nil {
+		return ErrInvalidMeta
+	}
+	var mval reflect.Value
+	if meta != nil {
+		mval = reflect.ValueOf(meta)
+		if !mval.Type().AssignableTo(c.metatyp) {
+			return ErrInvalidMeta
+		}
+	}
+	return c.callMeta(context.Background(), data, mval)
+}
 
-	c.makeDynamicCall(val)
+func (c *Caller) call(ctx context.Context, data []byte) error {
+	return c.callMeta(ctx, data, reflect.Value{})
+}
+
+// callMeta calls the function with the given metadata. An invalid meta means
+// none was given and the zero value is passed instead.
+func (c *Caller) callMeta(ctx context.Context, data []byte, meta reflect.Value) error {
+	if c.ObserveSize != nil {
+		c.ObserveSize(len(data))
+	}
+	val, err := c.unmarshal(data)
+	if err != nil && c.Fallback != nil {
+		if c.DryRun {
+			return c.Fallback.Valid(data)
+		}
+		err = c.delegate(ctx, data, meta)
+		c.notify(nil, err)
+		return err
+	}
+	if !meta.IsValid() {
+		meta = c.zeroMeta
+	}
+	if err != nil {
+		c.notify(nil, err)
+		var typErr *json.UnmarshalTypeError
+		if c.SkipTypeMismatch && errors.As(err, &typErr) {
+			return nil
+		}
+		return c.wrapError(err, data)
+	}
+	if c.withRaw {
+		meta = c.defaultMeta(data)
+	}
+	return c.dispatch(ctx, val, meta, data, c.OnEmptyObject != nil && isEmptyObject(data))
+}
+
+// dispatch calls the function with a decoded value, unless it is a dry run or
+// a duplicate. The payload is only used for errors and may be nil.
+func (c *Caller) dispatch(ctx context.Context, val, meta reflect.Value, data []byte, empty bool) error {
+	if c.DryRun {
+		return nil
+	}
+	key, dup, err := c.isDuplicate(val)
+	if err != nil || dup {
+		return c.wrapError(err, data)
+	}
+	if empty && c.OnEmptyObject != nil {
+		c.OnEmptyObject()
+	}
+
+	if c.token != nil {
+		setCancelToken(ctx, val, c.token)
+	}
+	err = c.invoke(ctx, val, meta)
+	if err != nil {
+		err = c.forget(key, err)
+	}
+	err = c.wrapError(err, data)
+	if len(c.Listeners) > 0 {
+		c.notify(val.Elem().Interface(), err)
+	}
+	return err
+}
+
+// defaultMeta returns the metadata passed to the function when none is given,
+// which is the raw payload for functions accepting one.
+func (c *Caller) defaultMeta(data []byte) reflect.Value {
+	if c.withRaw {
+		return reflect.ValueOf(data)
+	}
+	return c.zeroMeta
+}
+
+// delegate passes the payload to Fallback along with the metadata, which the
+// fallback function must accept as well.
+func (c *Caller) delegate(ctx context.Context, data []byte, meta reflect.Value) error {
+	if c.withRaw {
+		// The raw payload is not metadata given by the caller
+		meta = reflect.Value{}
+	}
+	if meta.IsValid() && (c.Fallback.metatyp == nil || !meta.Type().AssignableTo(c.Fallback.metatyp)) {
+		return c.wrapError(ErrInvalidMeta, data)
+	}
+	return c.Fallback.callMeta(ctx, data, meta)
+}
+
+func (c *Caller) notify(v interface{}, err error) {
+	for _, fn := range c.Listeners {
+		fn(v, err)
+	}
+}
+
+func (c *Caller) invoke(ctx context.Context, val, meta reflect.Value) error {
+	err := c.makeDynamicCall(ctx, val, meta)
+	if err != nil && c.AnnotateType {
+		err = fmt.Errorf("%s: %w", c.argtyp, err)
+	}
+	if c.AfterDispatch != nil {
+		c.AfterDispatch(val.Elem().Interface(), err)
+	}
+	return err
+}
+
+func isEmptyObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return false
+	}
+	return len(bytes.TrimSpace(data[1:len(data)-1])) == 0
+}
+
+func isMetaType(typ reflect.Type) bool {
+	if typ == bytesType || isEmitType(typ) {
+		return true
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+func checkArgType(typ reflect.Type) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if !decodableKinds[typ.Kind()] {
+		return argTypeError{kind: typ.Kind()}
+	}
 	return nil
 }
 
+// kindNames holds human readable names of kinds that can not be decoded into.
+var kindNames = map[reflect.Kind]string{
+	reflect.Chan:          "channel",
+	reflect.Func:          "function",
+	reflect.Complex64:     "complex number",
+	reflect.Complex128:    "complex number",
+	reflect.UnsafePointer: "unsafe pointer",
+}
+
+// argTypeError describes an unsupported argument type in plain words. It
+// matches ErrUnsupportedArgType when compared with errors.Is.
+type argTypeError struct {
+	kind reflect.Kind
+}
+
+func (e argTypeError) Error() string {
+	name, ok := kindNames[e.kind]
+	if !ok {
+		name = e.kind.String()
+	}
+	return "caller: cannot decode into " + name + " type"
+}
+
+func (e argTypeError) Is(target error) bool {
+	return target == ErrUnsupportedArgType
+}
+
 func (c *Caller) unmarshal(data []byte) (val reflect.Value, err error) {
-	val = c.newValue()
-	err = c.Unmarshaller(data, val.Interface())
+	if val, err = c.prepareValue(); err != nil {
+		return
+	}
+	if c.RejectDuplicateKeys || c.MaxDepth > 0 {
+		if err = scanJSONInto(data, c.argtyp, c.tagKey(), c.MaxDepth, c.RejectDuplicateKeys); err != nil {
+			return
+		}
+	}
+	if c.ResolveAliases && len(c.aliased) > 0 {
+		data = resolveAliases(data, c.aliased, c.tagKey())
+	}
+	if c.EnforceRequired && len(c.required) > 0 {
+		if err = checkRequired(data, c.required, c.tagKey()); err != nil {
+			return
+		}
+	}
+	if c.EpochUnit > 0 && len(c.times) > 0 {
+		if data, err = convertEpochTimes(data, c.times, c.tagKey(), c.EpochUnit); err != nil {
+			return
+		}
+	}
+	if c.TagKey != "" {
+		err = unmarshalTagged(data, val.Interface(), c.TagKey)
+	} else {
+		err = c.Unmarshaller(data, val.Interface())
+	}
+	if err == nil {
+		c.finishValue(val)
+	}
+	return
+}
+
+// decodeMap works like unmarshal for a map that was already parsed from JSON.
+func (c *Caller) decodeMap(m map[string]interface{}) (val reflect.Value, err error) {
+	if val, err = c.prepareValue(); err != nil {
+		return
+	}
+	if c.ResolveAliases && len(c.aliased) > 0 {
+		m = resolveMapAliases(m, c.aliased, c.tagKey())
+	}
+	if c.EnforceRequired && len(c.required) > 0 {
+		if err = checkRequiredMap(m, c.required, c.tagKey()); err != nil {
+			return
+		}
+	}
+	if err = assignValue(val.Elem(), m, c.tagKey()); err == nil {
+		c.finishValue(val)
+	}
+	return
+}
+
+// prepareValue creates the decode target and fills it with the Template and
+// allocated pointers.
+func (c *Caller) prepareValue() (val reflect.Value, err error) {
+	if c.NewValue != nil {
+		val = reflect.ValueOf(c.NewValue())
+		if !val.IsValid() || val.Type() != reflect.PtrTo(c.argtyp) {
+			return reflect.Value{}, ErrInvalidValue
+		}
+	} else {
+		val = c.newValue()
+	}
+	if c.Template != nil {
+		if err = c.applyTemplate(val); err != nil {
+			return
+		}
+	}
+	if c.AllocPointers && len(c.alloc) > 0 {
+		allocFields(val, c.alloc)
+	}
 	return
 }
 
-func (c *Caller) makeDynamicCall(val reflect.Value) {
-	c.fun.Call([]reflect.Value{val.Elem()})
+// finishValue runs the passes that follow decoding.
+func (c *Caller) finishValue(val reflect.Value) {
+	if c.AllocPointers && len(c.alloc) > 0 {
+		// Explicit nulls in the payload clear the pointers again
+		allocFields(val, c.alloc)
+	}
+	applyTransforms(val, c.transforms)
+}
+
+// tagKey returns the struct tag key field names are read from.
+func (c *Caller) tagKey() string {
+	if c.TagKey != "" {
+		return c.TagKey
+	}
+	return "json"
+}
+
+func (c *Caller) makeDynamicCall(ctx context.Context, val, meta reflect.Value) error {
+	args := make([]reflect.Value, 0, 3)
+	if c.withCtx {
+		args = append(args, reflect.ValueOf(ctx))
+	}
+	args = append(args, val.Elem())
+	if c.metatyp != nil {
+		args = append(args, meta)
+	}
+
+	var out []reflect.Value
+	if c.variadic {
+		out = c.fun.CallSlice(args)
+	} else {
+		out = c.fun.Call(args)
+	}
+	if !c.withErr || out[0].IsNil() {
+		return nil
+	}
+	return out[0].Interface().(error)
 }
 
 func (c *Caller) newValue() reflect.Value {
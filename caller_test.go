@@ -1,11 +1,18 @@
 package caller
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 //
@@ -24,6 +31,21 @@ func testFun(m testMessage) {
 
 func testFunSilent(_ testMessage) {}
 
+type testCustomMessage struct {
+	Body   string
+	Length int
+}
+
+func (m *testCustomMessage) UnmarshalJSON(data []byte) error {
+	var raw testMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Body = raw.Body
+	m.Length = len(raw.Body)
+	return nil
+}
+
 //
 // Tests
 //
@@ -38,6 +60,46 @@ func TestNewCallerSuccess(t *testing.T) {
 	}
 }
 
+func TestNewCallerWithDefaultUnmarshaller(t *testing.T) {
+	var called bool
+	DefaultUnmarshaller = func(data []byte, v interface{}) error {
+		called = true
+		return json.Unmarshal(data, v)
+	}
+	defer func() { DefaultUnmarshaller = nil }()
+
+	c, _ := New(testFunSilent)
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !called {
+		t.Error("Expected DefaultUnmarshaller to be used")
+	}
+}
+
+func TestMustNew(t *testing.T) {
+	var called bool
+	c := MustNew(testFunSilent).WithUnmarshaller(func(data []byte, v interface{}) error {
+		called = true
+		return json.Unmarshal(data, v)
+	})
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !called {
+		t.Error("Expected configured unmarshaller to be used")
+	}
+}
+
+func TestMustNewPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrInvalidFunctionType {
+			t.Errorf("Expected panic with ErrInvalidFunctionType, got: %v", r)
+		}
+	}()
+	MustNew(1)
+}
+
 func TestNewCallerWithNonFunc(t *testing.T) {
 	c, err := New(1)
 	if err != ErrInvalidFunctionType {
@@ -70,6 +132,94 @@ func TestNewCallerWithFuncReturnValue(t *testing.T) {
 	}
 }
 
+func TestNewCallerWithSupportedArgType(t *testing.T) {
+	c, err := New(func(_ *[]int) {})
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if c == nil {
+		t.Error("Expected an instance of Caller, got nil")
+	}
+}
+
+func TestNewCallerWithUnsupportedArgType(t *testing.T) {
+	c, err := New(func(_ chan int) {})
+	if !errors.Is(err, ErrUnsupportedArgType) {
+		t.Errorf("Expected ErrUnsupportedArgType, got: %v", err)
+	}
+	if c != nil {
+		t.Error("Expected nil, got an instance of Caller")
+	}
+}
+
+func TestNewCallerWithComplexArgType(t *testing.T) {
+	c, err := New(func(_ complex128) {})
+	if !errors.Is(err, ErrUnsupportedArgType) {
+		t.Errorf("Expected ErrUnsupportedArgType, got: %v", err)
+	}
+	if c != nil {
+		t.Error("Expected nil, got an instance of Caller")
+	}
+}
+
+func TestNewCallerUnsupportedArgTypeMessage(t *testing.T) {
+	_, err := New(func(_ chan int) {})
+	if err == nil || err.Error() != "caller: cannot decode into channel type" {
+		t.Errorf("Expected channel type error, got: %v", err)
+	}
+	_, err = New(func(_ *func()) {})
+	if err == nil || err.Error() != "caller: cannot decode into function type" {
+		t.Errorf("Expected function type error, got: %v", err)
+	}
+	_, err = New(func(_ complex64) {})
+	if err == nil || err.Error() != "caller: cannot decode into complex number type" {
+		t.Errorf("Expected complex number type error, got: %v", err)
+	}
+}
+
+func TestNewCallerWithContextFunc(t *testing.T) {
+	c, err := New(func(_ context.Context, _ testMessage) {})
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if c == nil {
+		t.Error("Expected an instance of Caller, got nil")
+	}
+}
+
+func TestNewCallerWithErrorFunc(t *testing.T) {
+	c, err := New(func(_ context.Context, _ testMessage) error { return nil })
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if c == nil {
+		t.Error("Expected an instance of Caller, got nil")
+	}
+}
+
+func TestInputKinds(t *testing.T) {
+	c, _ := New(func(_ context.Context, _ testMessage) {})
+
+	kinds := c.InputKinds()
+	if len(kinds) != 2 || kinds[0] != reflect.Interface || kinds[1] != reflect.Struct {
+		t.Errorf("Expected [interface struct], got %v", kinds)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	c, _ := New(testFun)
+
+	if !c.Matches(func(testMessage) {}) {
+		t.Error("Expected Caller to match a prototype of the same type")
+	}
+	if c.Matches(func(testMessage) error { return nil }) {
+		t.Error("Expected Caller not to match a prototype of a different type")
+	}
+	if c.Matches(nil) {
+		t.Error("Expected Caller not to match nil")
+	}
+}
+
 func TestCallSuccess(t *testing.T) {
 	c, err := New(testFun)
 	if err != nil {
@@ -87,6 +237,20 @@ func TestCallSuccess(t *testing.T) {
 	}
 }
 
+func TestCallString(t *testing.T) {
+	c, _ := New(testFun)
+
+	out := captureStdoutAround(func() {
+		if err := c.CallString(testPayload); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+
+	if string(out) != "Success!" {
+		t.Errorf("Expected output to be %q, got %q", "Success!", out)
+	}
+}
+
 func TestCallFalure(t *testing.T) {
 	c, _ := New(testFunSilent)
 
@@ -96,6 +260,818 @@ func TestCallFalure(t *testing.T) {
 	}
 }
 
+type testMeta struct {
+	Topic string
+}
+
+func TestCallWithMeta(t *testing.T) {
+	var gotMsg testMessage
+	var gotMeta testMeta
+	c, err := New(func(m testMessage, meta testMeta) {
+		gotMsg, gotMeta = m, meta
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := c.CallWithMeta([]byte(testPayload), testMeta{Topic: "news"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if gotMsg.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", gotMsg.Body)
+	}
+	if gotMeta.Topic != "news" {
+		t.Errorf("Expected topic to be %q, got %q", "news", gotMeta.Topic)
+	}
+
+	if err := c.CallWithMeta([]byte(testPayload), "news"); err != ErrInvalidMeta {
+		t.Errorf("Expected ErrInvalidMeta, got: %v", err)
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if gotMeta.Topic != "" {
+		t.Errorf("Expected zero metadata, got %+v", gotMeta)
+	}
+}
+
+func TestCallWithMetaNoMetaArgument(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	if err := c.CallWithMeta([]byte(testPayload), testMeta{}); err != ErrInvalidMeta {
+		t.Errorf("Expected ErrInvalidMeta, got: %v", err)
+	}
+}
+
+func TestCallWithRawPayload(t *testing.T) {
+	var gotMsg testMessage
+	var gotRaw []byte
+	c, err := New(func(m testMessage, raw []byte) {
+		gotMsg, gotRaw = m, raw
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if gotMsg.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", gotMsg.Body)
+	}
+	if string(gotRaw) != testPayload {
+		t.Errorf("Expected raw payload %q, got %q", testPayload, gotRaw)
+	}
+}
+
+func TestCallContextError(t *testing.T) {
+	c, _ := New(func(ctx context.Context, _ testMessage) error {
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CallContext(ctx, []byte(testPayload)); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if err := c.CallContext(context.Background(), []byte(testPayload)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestCallWithValue(t *testing.T) {
+	type ctxKey struct{}
+	var got interface{}
+	c, _ := New(func(ctx context.Context, _ testMessage) {
+		got = ctx.Value(ctxKey{})
+	})
+
+	err := c.CallWithValue(context.Background(), []byte(testPayload), ctxKey{}, "abc123")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != "abc123" {
+		t.Errorf("Expected context value to be %q, got %v", "abc123", got)
+	}
+}
+
+func TestCallContextTimeout(t *testing.T) {
+	finished := make(chan struct{})
+	c, _ := New(func(ctx context.Context, _ testMessage) {
+		<-ctx.Done()
+		close(finished)
+	})
+
+	err := c.CallContextTimeout(context.Background(), []byte(testPayload), 10*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("Expected handler to observe context cancellation")
+	}
+}
+
+func TestCallContextTimeoutSuccess(t *testing.T) {
+	c, _ := New(func(_ context.Context, _ testMessage) {})
+
+	err := c.CallContextTimeout(context.Background(), []byte(testPayload), time.Second)
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestCallConcat(t *testing.T) {
+	var bodies []string
+	c, _ := New(func(m testMessage) { bodies = append(bodies, m.Body) })
+
+	err := c.CallConcat([]byte(`{"body":"a"}{"body":"b"}{"body":"c"}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(bodies) != 3 || bodies[0] != "a" || bodies[1] != "b" || bodies[2] != "c" {
+		t.Errorf("Expected three calls with bodies a, b, c, got %q", bodies)
+	}
+}
+
+func TestCallConcatFailure(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	if err := c.CallConcat([]byte(`{"body":"a"}{`)); err == nil {
+		t.Error("Expected decoding error, got nil")
+	}
+}
+
+func TestCallStreamContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int
+	c, _ := New(func(_ testMessage) {
+		if calls++; calls == 2 {
+			cancel()
+		}
+	})
+
+	r := strings.NewReader(strings.Repeat(testPayload+"\n", 5))
+	if err := c.CallStreamContext(ctx, r); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected processing to stop after two calls, got %d", calls)
+	}
+}
+
+func frame(payload string) []byte {
+	buf := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	return append(buf, payload...)
+}
+
+func TestCallFramed(t *testing.T) {
+	var bodies []string
+	c, _ := New(func(m testMessage) { bodies = append(bodies, m.Body) })
+
+	stream := append(frame(`{"body":"a"}`), frame(`{"body":"b"}`)...)
+	if err := c.CallFramed(bytes.NewReader(stream)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(bodies) != 2 || bodies[0] != "a" || bodies[1] != "b" {
+		t.Errorf("Expected two calls with bodies a, b, got %q", bodies)
+	}
+}
+
+func TestCallFramedTruncated(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	full := frame(testPayload)
+	for _, stream := range [][]byte{full[:2], full[:len(full)-1]} {
+		if err := c.CallFramed(bytes.NewReader(stream)); err != ErrTruncatedFrame {
+			t.Errorf("Expected ErrTruncatedFrame for %q, got: %v", stream, err)
+		}
+	}
+}
+
+func TestCallChan(t *testing.T) {
+	var bodies []string
+	c, _ := New(func(m testMessage) { bodies = append(bodies, m.Body) })
+
+	ch := make(chan []byte, 3)
+	ch <- []byte(`{"body":"a"}`)
+	ch <- []byte(`{"body":"b"}`)
+	ch <- []byte(`{"body":"c"}`)
+	close(ch)
+
+	if err := c.CallChan(context.Background(), ch); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(bodies) != 3 || bodies[0] != "a" || bodies[1] != "b" || bodies[2] != "c" {
+		t.Errorf("Expected three calls with bodies a, b, c, got %q", bodies)
+	}
+}
+
+func TestCallChanContinueOnError(t *testing.T) {
+	var calls int
+	c, _ := New(func(_ testMessage) { calls++ })
+	c.ContinueOnError = true
+
+	ch := make(chan []byte, 3)
+	ch <- []byte(`{"body":"a"}`)
+	ch <- []byte(`{`)
+	ch <- []byte(`{"body":"c"}`)
+	close(ch)
+
+	if err := c.CallChan(context.Background(), ch); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+	if calls != 2 {
+		t.Errorf("Expected two calls, got %d", calls)
+	}
+}
+
+func TestCallChanCancel(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CallChan(ctx, make(chan []byte)); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestCallNumberOverflow(t *testing.T) {
+	c, _ := New(func(_ struct {
+		Level int8 `json:"level"`
+	}) {
+	})
+
+	err := c.Call([]byte(`{"level":300}`))
+	var typErr *json.UnmarshalTypeError
+	if !errors.As(err, &typErr) {
+		t.Errorf("Expected json.UnmarshalTypeError, got: %v", err)
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c, _ := New(func(_ testMessage) { <-release })
+	c.DefaultTimeout = 10 * time.Millisecond
+
+	if err := c.Call([]byte(testPayload)); err != ErrCallTimeout {
+		t.Errorf("Expected ErrCallTimeout, got: %v", err)
+	}
+}
+
+func TestDefaultTimeoutErrorWrapper(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c, _ := New(func(_ testMessage) { <-release })
+	c.DefaultTimeout = 10 * time.Millisecond
+	c.ErrorWrapper = func(err error, data []byte) error {
+		return fmt.Errorf("wrapped: %w", err)
+	}
+
+	err := c.Call([]byte(testPayload))
+	if !errors.Is(err, ErrCallTimeout) || !strings.HasPrefix(err.Error(), "wrapped: ") {
+		t.Errorf("Expected wrapped ErrCallTimeout, got: %v", err)
+	}
+}
+
+func TestCallWithJSONNumber(t *testing.T) {
+	type amounts struct {
+		Exact json.Number `json:"exact"`
+		Any   interface{} `json:"any"`
+	}
+	var got amounts
+	c, _ := New(func(a amounts) { got = a })
+
+	if err := c.Call([]byte(`{"exact":12345678901234567890.5,"any":1}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Exact != "12345678901234567890.5" {
+		t.Errorf("Expected exact number %q, got %q", "12345678901234567890.5", got.Exact)
+	}
+	if _, ok := got.Any.(float64); !ok {
+		t.Errorf("Expected interface field to hold a float64, got %T", got.Any)
+	}
+}
+
+func TestCallCustomUnmarshaler(t *testing.T) {
+	var got testCustomMessage
+	c, _ := New(func(m testCustomMessage) { got = m })
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+	if got.Length != len("Success!") {
+		t.Errorf("Expected computed length to be %d, got %d", len("Success!"), got.Length)
+	}
+}
+
+func TestCallWithMapArgument(t *testing.T) {
+	var got map[string]int
+	c, _ := New(func(counts map[string]int) { got = counts })
+
+	if err := c.Call([]byte(`{"a":1,"b":2}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got == nil {
+		t.Fatal("Expected a map, got nil")
+	}
+	if got["a"] != 1 || got["b"] != 2 {
+		t.Errorf("Expected map[a:1 b:2], got %v", got)
+	}
+}
+
+func TestCallWithNamedPrimitiveArgument(t *testing.T) {
+	type status string
+	var got interface{}
+	c, _ := New(func(s status) { got = s })
+
+	if err := c.Call([]byte(`"active"`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if s, ok := got.(status); !ok || s != "active" {
+		t.Errorf("Expected status %q, got %T %v", "active", got, got)
+	}
+}
+
+type testEvent struct {
+	Payload interface{}
+}
+
+func (e *testEvent) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Kind string          `json:"kind"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	switch head.Kind {
+	case "message":
+		var m testMessage
+		e.Payload = &m
+	default:
+		return fmt.Errorf("unknown kind %q", head.Kind)
+	}
+	return json.Unmarshal(head.Data, e.Payload)
+}
+
+func TestCallDispatchingUnmarshaler(t *testing.T) {
+	var got testEvent
+	c, _ := New(func(e testEvent) { got = e })
+
+	if err := c.Call([]byte(`{"kind":"message","data":` + testPayload + `}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	m, ok := got.Payload.(*testMessage)
+	if !ok || m.Body != "Success!" {
+		t.Errorf("Expected payload to be a testMessage with body %q, got %#v", "Success!", got.Payload)
+	}
+	if err := c.Call([]byte(`{"kind":"other"}`)); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
+
+func TestCallWithPointerToSlice(t *testing.T) {
+	var got *[]testMessage
+	c, _ := New(func(items *[]testMessage) { got = items })
+
+	if err := c.Call([]byte(`null`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != nil {
+		t.Errorf("Expected nil for null, got %v", *got)
+	}
+
+	if err := c.Call([]byte(`[]`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got == nil || *got == nil || len(*got) != 0 {
+		t.Errorf("Expected a non-nil empty slice for [], got %#v", got)
+	}
+}
+
+func TestCallVariadic(t *testing.T) {
+	var got []int
+	c, err := New(func(ids ...int) { got = ids })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := c.Call([]byte(`[1,2,3]`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("Expected three arguments 1, 2, 3, got %v", got)
+	}
+}
+
+func TestObserveSize(t *testing.T) {
+	c, _ := New(testFunSilent)
+	var sizes []int
+	c.ObserveSize = func(n int) { sizes = append(sizes, n) }
+
+	c.Call([]byte(testPayload))
+	c.Call([]byte("{"))
+
+	if len(sizes) != 2 || sizes[0] != len(testPayload) || sizes[1] != 1 {
+		t.Errorf("Expected observed sizes [%d 1], got %v", len(testPayload), sizes)
+	}
+}
+
+type testPrivateFields struct {
+	Body    string `json:"body" msg:"body" transform:"trim"`
+	private string `transform:"upper"`
+	counter *int   `transform:"trim"`
+	Labels  map[string]string
+	labels  map[string]string
+}
+
+func TestCallWithPrivateFields(t *testing.T) {
+	var got testPrivateFields
+	c, err := New(func(m testPrivateFields) { got = m })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	n := 1
+	c.Template = testPrivateFields{
+		private: "kept",
+		counter: &n,
+		Labels:  map[string]string{"a": "b"},
+		labels:  map[string]string{"c": "d"},
+	}
+	c.DeepCopyTemplate = true
+
+	for _, key := range []string{"", "msg"} {
+		c.TagKey = key
+		if err := c.Call([]byte(`{"body":" Success! ","private":"x"}`)); err != nil {
+			t.Fatal(err.Error())
+		}
+		if got.Body != "Success!" {
+			t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+		}
+		if got.private != "kept" || got.counter != &n || got.labels["c"] != "d" {
+			t.Errorf("Expected unexported fields to be copied as is, got %+v", got)
+		}
+		if got.Labels["a"] != "b" {
+			t.Errorf("Expected exported fields to be copied, got %+v", got.Labels)
+		}
+	}
+}
+
+func TestNewValue(t *testing.T) {
+	type message struct {
+		Body  string `json:"body"`
+		Queue chan string
+	}
+	var got message
+	c, _ := New(func(m message) { got = m })
+	c.NewValue = func() interface{} {
+		return &message{Queue: make(chan string, 1)}
+	}
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" || got.Queue == nil {
+		t.Errorf("Expected decoded body and initialized channel, got %+v", got)
+	}
+
+	c.NewValue = func() interface{} { return message{} }
+	if err := c.Call([]byte(testPayload)); err != ErrInvalidValue {
+		t.Errorf("Expected ErrInvalidValue, got: %v", err)
+	}
+}
+
+func TestAfterDispatch(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	var got interface{}
+	c.AfterDispatch = func(v interface{}, err error) {
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+		got = v
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	msg, ok := got.(testMessage)
+	if !ok {
+		t.Fatalf("Expected AfterDispatch to receive testMessage, got %T", got)
+	}
+	if msg.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", msg.Body)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var called bool
+	c, _ := New(func(_ testMessage) { called = true })
+	c.DryRun = true
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := c.Call([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+	if called {
+		t.Error("Expected function not to be called in dry run mode")
+	}
+}
+
+func TestDryRunFallback(t *testing.T) {
+	var calls int
+	c, _ := New(func(_ struct {
+		Body int `json:"body"`
+	}) {
+		calls++
+	})
+	c.Fallback, _ = New(func(_ testMessage) { calls++ })
+	c.DryRun = true
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected fallback to accept the payload, got: %v", err)
+	}
+	if err := c.Call([]byte(`{"body":[]}`)); err == nil {
+		t.Error("Expected unmarshalling error from fallback, got nil")
+	}
+	if calls != 0 {
+		t.Errorf("Expected no functions to be called in dry run mode, got %d calls", calls)
+	}
+}
+
+func TestSkipTypeMismatch(t *testing.T) {
+	var calls int
+	c, _ := New(func(_ testMessage) { calls++ })
+	c.SkipTypeMismatch = true
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := c.Call([]byte(`{"body":42}`)); err != nil {
+		t.Errorf("Expected mismatching payload to be skipped, got: %v", err)
+	}
+	if err := c.Call([]byte("{")); err == nil {
+		t.Error("Expected syntax error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("Expected one call, got %d", calls)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var primary, fallback int
+	c, _ := New(func(_ struct {
+		Body int `json:"body"`
+	}) {
+		primary++
+	})
+	c.Fallback, _ = New(func(_ testMessage) { fallback++ })
+
+	if err := c.Call([]byte(`{"body":1}`)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected fallback to accept the payload, got: %v", err)
+	}
+	if primary != 1 || fallback != 1 {
+		t.Errorf("Expected one call to each function, got %d and %d", primary, fallback)
+	}
+	if err := c.Call([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error from fallback, got nil")
+	}
+}
+
+func TestFallbackWithMeta(t *testing.T) {
+	var got testMeta
+	c, _ := New(func(_ struct {
+		Body int `json:"body"`
+	}, _ testMeta) {
+	})
+	c.Fallback, _ = New(func(_ testMessage, meta testMeta) { got = meta })
+
+	if err := c.CallWithMeta([]byte(testPayload), testMeta{Topic: "orders"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Topic != "orders" {
+		t.Errorf("Expected fallback to get metadata, got %+v", got)
+	}
+
+	c.Fallback, _ = New(testFunSilent)
+	if err := c.CallWithMeta([]byte(testPayload), testMeta{}); err != ErrInvalidMeta {
+		t.Errorf("Expected ErrInvalidMeta, got: %v", err)
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected fallback without metadata to be called, got: %v", err)
+	}
+}
+
+func TestFallbackWithEmit(t *testing.T) {
+	c, _ := New(func(_ struct {
+		Body int `json:"body"`
+	}, _ func(testMessage)) {
+	})
+	c.Fallback, _ = New(func(m testMessage, emit func(testMessage)) { emit(m) })
+
+	var events int
+	err := c.CallEmit([]byte(testPayload), func(b []byte) error {
+		events++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if events != 1 {
+		t.Errorf("Expected fallback event to reach the sink, got %d events", events)
+	}
+}
+
+func TestFallbackListeners(t *testing.T) {
+	fallbackErr := errors.New("fallback failed")
+	c, _ := New(func(_ struct {
+		Body int `json:"body"`
+	}) {
+	})
+	c.Fallback, _ = New(func(_ testMessage) error { return fallbackErr })
+
+	var calls int
+	var gotErr error
+	c.Listeners = append(c.Listeners, func(v interface{}, err error) {
+		calls++
+		gotErr = err
+	})
+	if err := c.Call([]byte(testPayload)); err != fallbackErr {
+		t.Errorf("Expected fallback error, got: %v", err)
+	}
+	if calls != 1 || gotErr != fallbackErr {
+		t.Errorf("Expected listener to get the fallback error once, got %d calls with %v", calls, gotErr)
+	}
+}
+
+func TestOnEmptyObject(t *testing.T) {
+	got := testMessage{Body: "unset"}
+	c, _ := New(func(m testMessage) { got = m })
+	var empty int
+	c.OnEmptyObject = func() { empty++ }
+
+	if err := c.Call([]byte(" { } ")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if empty != 1 {
+		t.Errorf("Expected OnEmptyObject to fire once, got %d", empty)
+	}
+	if got != (testMessage{}) {
+		t.Errorf("Expected zero value, got %+v", got)
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if empty != 1 {
+		t.Errorf("Expected OnEmptyObject not to fire for a non-empty object, got %d", empty)
+	}
+}
+
+func TestValid(t *testing.T) {
+	var called bool
+	c, _ := New(func(_ testMessage) { called = true })
+
+	if err := c.Valid([]byte(testPayload)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := c.Valid([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+	if called {
+		t.Error("Expected function not to be called")
+	}
+}
+
+func TestListeners(t *testing.T) {
+	c, _ := New(testFunSilent)
+	var values []interface{}
+	var errs []error
+	c.Listeners = append(c.Listeners, func(v interface{}, err error) {
+		values = append(values, v)
+		errs = append(errs, err)
+	})
+
+	c.Call([]byte(testPayload))
+	c.Call([]byte("{"))
+
+	if len(values) != 2 {
+		t.Fatalf("Expected listener to be invoked twice, got %d", len(values))
+	}
+	if m, ok := values[0].(testMessage); !ok || m.Body != "Success!" || errs[0] != nil {
+		t.Errorf("Expected decoded value and no error, got %#v and %v", values[0], errs[0])
+	}
+	if values[1] != nil || errs[1] == nil {
+		t.Errorf("Expected nil value and an error, got %#v and %v", values[1], errs[1])
+	}
+}
+
+func TestAnnotateType(t *testing.T) {
+	errFailed := errors.New("failed")
+	c, _ := New(func(_ testMessage) error { return errFailed })
+	c.AnnotateType = true
+
+	err := c.Call([]byte(testPayload))
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected function error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "testMessage") {
+		t.Errorf("Expected error to mention the argument type, got: %v", err)
+	}
+}
+
+func TestUnmarshalAndInvoke(t *testing.T) {
+	var got testMessage
+	c, _ := New(func(m testMessage) { got = m })
+
+	val, err := c.Unmarshal([]byte(testPayload))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	val.Interface().(*testMessage).Body += " Enriched!"
+	if err := c.Invoke(val); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success! Enriched!" {
+		t.Errorf("Expected body to be %q, got %q", "Success! Enriched!", got.Body)
+	}
+
+	if err := c.Invoke(reflect.ValueOf(got)); err != ErrInvalidValue {
+		t.Errorf("Expected ErrInvalidValue, got: %v", err)
+	}
+}
+
+func TestCallValueAll(t *testing.T) {
+	var first, second testMessage
+	c1, _ := New(func(m testMessage) { first = m })
+	c2, _ := New(func(m testMessage) { second = m })
+
+	err := CallValueAll(testMessage{Body: "Success!"}, c1, c2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if first.Body != "Success!" || second.Body != "Success!" {
+		t.Errorf("Expected both functions to receive the value, got %+v and %+v", first, second)
+	}
+}
+
+func TestCallValueAllErrors(t *testing.T) {
+	errFailed := errors.New("failed")
+	var called bool
+	c1, _ := New(func(_ testMessage) error { return errFailed })
+	c2, _ := New(func(_ int) {})
+	c3, _ := New(func(_ testMessage) { called = true })
+
+	err := CallValueAll(testMessage{}, c1, c2, c3)
+	if !errors.Is(err, errFailed) || !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected joined errors, got: %v", err)
+	}
+	if !called {
+		t.Error("Expected remaining functions to be called")
+	}
+}
+
+func TestCallTimed(t *testing.T) {
+	c, _ := New(func(_ testMessage) { time.Sleep(time.Millisecond) })
+
+	decode, handle, err := c.CallTimed([]byte(testPayload))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if decode < 0 {
+		t.Errorf("Expected non-negative decode duration, got %v", decode)
+	}
+	if handle < time.Millisecond {
+		t.Errorf("Expected handle duration of at least 1ms, got %v", handle)
+	}
+}
+
+func TestCallTimedWithRawPayload(t *testing.T) {
+	var raw string
+	c, _ := New(func(_ testMessage, data []byte) { raw = string(data) })
+
+	if _, _, err := c.CallTimed([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if raw != testPayload {
+		t.Errorf("Expected raw payload %q, got %q", testPayload, raw)
+	}
+}
+
 func TestUnmarshalSuccess(t *testing.T) {
 	c, _ := New(testFunSilent)
 
@@ -139,6 +1115,7 @@ func captureStdoutAround(f func()) []byte {
 
 func BenchmarkCaller(b *testing.B) {
 	c, _ := New(testFunSilent)
+	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
 		c.Call([]byte(testPayload))
@@ -170,9 +1147,11 @@ func BenchmarkStaticNew(b *testing.B) {
 func BenchmarkDynamicCall(b *testing.B) {
 	c, _ := New(testFunSilent)
 	val, _ := c.unmarshal([]byte(testPayload))
+	ctx := context.Background()
+	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		c.makeDynamicCall(val)
+		c.makeDynamicCall(ctx, val, reflect.Value{})
 	}
 }
 
@@ -0,0 +1,78 @@
+package caller
+
+import (
+	"context"
+	"reflect"
+)
+
+// CancelToken lets functions that do not accept a context abort work
+// cooperatively. When the argument type is a struct with an exported field of
+// this type, the field is set before every call and reports cancellation of
+// the call's context, like the one created by CallCancel. The field is
+// ignored by unmarshallers as long as it has no exported fields itself.
+type CancelToken struct {
+	done <-chan struct{}
+}
+
+var cancelTokenType = reflect.TypeOf(CancelToken{})
+
+// Done returns a channel that is closed when the call is cancelled. It
+// returns nil, a channel that is never ready, if the call can not be
+// cancelled.
+func (t CancelToken) Done() <-chan struct{} {
+	return t.done
+}
+
+// Cancelled reports whether the call was cancelled.
+func (t CancelToken) Cancelled() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// CallCancel works like Call but runs the Caller function asynchronously. It
+// returns a function that cancels the call and a channel the call's result is
+// sent to. Cancellation only works if the function cooperates, either by
+// accepting a context or by checking a CancelToken field of its argument.
+func (c *Caller) CallCancel(data []byte) (cancel func(), done <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	res := make(chan error, 1)
+	go func() {
+		defer cancel()
+		res <- c.call(ctx, data)
+	}()
+	return cancel, res
+}
+
+// findCancelToken returns the index of the top-level CancelToken field of
+// the argument type, or nil if there is none.
+func findCancelToken(typ reflect.Type) []int {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Type == cancelTokenType && f.PkgPath == "" {
+			return f.Index
+		}
+	}
+	return nil
+}
+
+// setCancelToken sets the CancelToken field of the decoded value, which is a
+// pointer to the argument type.
+func setCancelToken(ctx context.Context, val reflect.Value, index []int) {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	val.FieldByIndex(index).Set(reflect.ValueOf(CancelToken{done: ctx.Done()}))
+}
@@ -0,0 +1,57 @@
+package caller
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testCancellableMessage struct {
+	Body  string `json:"body"`
+	Token CancelToken
+}
+
+func TestCallCancel(t *testing.T) {
+	started := make(chan struct{})
+	c, _ := New(func(m testCancellableMessage) error {
+		close(started)
+		for !m.Token.Cancelled() {
+			time.Sleep(time.Millisecond)
+		}
+		return context.Canceled
+	})
+
+	cancel, done := c.CallCancel([]byte(testPayload))
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected function to exit early after cancellation")
+	}
+}
+
+func TestCallCancelResult(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	_, done := c.CallCancel([]byte("{"))
+	if err := <-done; err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
+
+func TestCancelTokenWithCall(t *testing.T) {
+	var token CancelToken
+	c, _ := New(func(m *testCancellableMessage) { token = m.Token })
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.Cancelled() || token.Done() != nil {
+		t.Error("Expected token of a plain call never to be cancelled")
+	}
+}
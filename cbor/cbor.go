@@ -0,0 +1,20 @@
+// Package cbor provides an unmarshaller backed by fxamacker/cbor for
+// decoding CBOR payloads, which are common with constrained devices. It is
+// kept in its own package so that the dependency is only pulled in when it
+// is used.
+//
+//	c, _ := caller.New(fun)
+//	c.Unmarshaller = cbor.Unmarshal
+//
+// Field names are read from `cbor` struct tags. For fields without one the
+// `json` tag is used, so types shared with JSON callers need no extra tags.
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Unmarshal is an unmarshaller function that decodes CBOR data into v.
+func Unmarshal(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
@@ -0,0 +1,59 @@
+package cbor
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/localhots/caller"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+type testTaggedMessage struct {
+	Body string `cbor:"b" json:"body"`
+}
+
+func TestCall(t *testing.T) {
+	data, err := cbor.Marshal(testMessage{Body: "Success!"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got testMessage
+	c, _ := caller.New(func(m testMessage) { got = m })
+	c.Unmarshaller = Unmarshal
+	if err := c.Call(data); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallWithCBORTag(t *testing.T) {
+	data, err := cbor.Marshal(map[string]string{"b": "Success!"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got testTaggedMessage
+	c, _ := caller.New(func(m testTaggedMessage) { got = m })
+	c.Unmarshaller = Unmarshal
+	if err := c.Call(data); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallFailure(t *testing.T) {
+	c, _ := caller.New(func(_ testMessage) {})
+	c.Unmarshaller = Unmarshal
+
+	if err := c.Call([]byte{0xff}); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
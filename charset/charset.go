@@ -0,0 +1,24 @@
+// Package charset transcodes payloads to UTF-8 before they are unmarshalled,
+// which is needed for legacy producers that send JSON in encodings like
+// ISO-8859-1. It is kept in its own package so that golang.org/x/text is only
+// pulled in when it is used.
+//
+//	c, _ := caller.New(fun)
+//	c.Unmarshaller = charset.Unmarshaller(charmap.ISO8859_1, json.Unmarshal)
+package charset
+
+import (
+	"golang.org/x/text/encoding"
+)
+
+// Unmarshaller returns an unmarshaller function that decodes data from the
+// given encoding into UTF-8 and passes the result to the next unmarshaller.
+func Unmarshaller(enc encoding.Encoding, next func(data []byte, v interface{}) error) func(data []byte, v interface{}) error {
+	return func(data []byte, v interface{}) error {
+		utf8, err := enc.NewDecoder().Bytes(data)
+		if err != nil {
+			return err
+		}
+		return next(utf8, v)
+	}
+}
@@ -0,0 +1,27 @@
+package charset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/localhots/caller"
+	"golang.org/x/text/encoding/charmap"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+func TestCallLatin1(t *testing.T) {
+	var got testMessage
+	c, _ := caller.New(func(m testMessage) { got = m })
+	c.Unmarshaller = Unmarshaller(charmap.ISO8859_1, json.Unmarshal)
+
+	// "café" with é encoded as a single ISO-8859-1 byte
+	if err := c.Call([]byte("{\"body\":\"caf\xe9\"}")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "café" {
+		t.Errorf("Expected body to be %q, got %q", "café", got.Body)
+	}
+}
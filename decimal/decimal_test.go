@@ -0,0 +1,31 @@
+package decimal
+
+import (
+	"testing"
+
+	"github.com/localhots/caller"
+	"github.com/shopspring/decimal"
+)
+
+type testPayment struct {
+	Amount decimal.Decimal `json:"amount"`
+}
+
+func TestCallWithDecimal(t *testing.T) {
+	exp := decimal.RequireFromString("12.34")
+
+	for _, payload := range []string{`{"amount":12.34}`, `{"amount":"12.34"}`} {
+		var got testPayment
+		c, _ := caller.New(func(p testPayment) { got = p })
+
+		if err := c.Call([]byte(payload)); err != nil {
+			t.Fatal(err.Error())
+		}
+		if !got.Amount.Equal(exp) {
+			t.Errorf("Expected amount to be %s for %s, got %s", exp, payload, got.Amount)
+		}
+		if got.Amount.String() != "12.34" {
+			t.Errorf("Expected exact amount 12.34 for %s, got %s", payload, got.Amount)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Package decimal documents the recommended way of handling money and other
+// exact numeric values with callers.
+//
+// Decoding numbers into float64 loses precision, so amounts should be
+// declared using shopspring/decimal instead. decimal.Decimal implements
+// json.Unmarshaler and accepts both JSON numbers and strings, which means it
+// works with the default JSON unmarshaller out of the box:
+//
+//	type Payment struct {
+//		Amount decimal.Decimal `json:"amount"`
+//	}
+//
+//	c, _ := caller.New(func(p Payment) { ... })
+//	c.Call([]byte(`{"amount": 12.34}`))
+//
+// Set decimal.MarshalJSONWithoutQuotes to control how amounts are encoded
+// back. This package has no code of its own, it only holds the tests that
+// verify the pattern.
+package decimal
@@ -0,0 +1,10 @@
+module github.com/localhots/caller/decimal
+
+go 1.21
+
+require (
+	github.com/localhots/caller v0.0.0
+	github.com/shopspring/decimal v1.4.0
+)
+
+replace github.com/localhots/caller => ../
@@ -0,0 +1,77 @@
+package caller
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Describe returns a single line summary of the Caller, suitable for startup
+// logs. It includes the function name, the argument type and the options
+// that affect how payloads are decoded and the function is called, e.g.
+//
+//	caller{fn:handleOrder arg:orders.Order json ctx err}
+func (c *Caller) Describe() string {
+	parts := []string{
+		"fn:" + funcName(c.fun),
+		"arg:" + c.argtyp.String(),
+	}
+
+	switch {
+	case c.TagKey != "":
+		parts = append(parts, "tag:"+c.TagKey)
+	case sameFunc(c.Unmarshaller, json.Unmarshal):
+		parts = append(parts, "json")
+	default:
+		parts = append(parts, "custom")
+	}
+	if c.withCtx {
+		parts = append(parts, "ctx")
+	}
+	switch {
+	case c.withRaw:
+		parts = append(parts, "raw")
+	case c.withEmit:
+		parts = append(parts, "emit:"+c.metatyp.In(0).String())
+	case c.metatyp != nil:
+		parts = append(parts, "meta:"+c.metatyp.String())
+	}
+	if c.variadic {
+		parts = append(parts, "variadic")
+	}
+	if c.withErr {
+		parts = append(parts, "err")
+	}
+	if c.EnforceRequired {
+		parts = append(parts, "required")
+	}
+	if c.DefaultTimeout > 0 {
+		parts = append(parts, "timeout:"+c.DefaultTimeout.String())
+	}
+	if c.DryRun {
+		parts = append(parts, "dryrun")
+	}
+
+	return "caller{" + strings.Join(parts, " ") + "}"
+}
+
+// funcName returns the name of a function without its package path.
+func funcName(fun reflect.Value) string {
+	f := runtime.FuncForPC(fun.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func sameFunc(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
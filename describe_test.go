@@ -0,0 +1,39 @@
+package caller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	c, _ := New(testFun)
+
+	exp := "caller{fn:testFun arg:caller.testMessage json}"
+	if got := c.Describe(); got != exp {
+		t.Errorf("Expected description to be %q, got %q", exp, got)
+	}
+}
+
+func TestDescribeOptions(t *testing.T) {
+	c, _ := New(func(ctx context.Context, m testMessage, meta testMeta) error { return nil })
+	c.Unmarshaller = func(data []byte, v interface{}) error { return nil }
+	c.DefaultTimeout = time.Second
+
+	got := c.Describe()
+	for _, part := range []string{"fn:TestDescribeOptions.func1", "arg:caller.testMessage", " custom", " ctx", " meta:caller.testMeta", " err", " timeout:1s"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("Expected description %q to contain %q", got, part)
+		}
+	}
+}
+
+func TestDescribeTagKey(t *testing.T) {
+	c, _ := New(testFun)
+	c.TagKey = "msgpack"
+
+	if got := c.Describe(); !strings.Contains(got, " tag:msgpack") {
+		t.Errorf("Expected description %q to contain the tag key", got)
+	}
+}
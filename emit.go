@@ -0,0 +1,64 @@
+package caller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrInvalidEmit is an error that is returned by CallEmit when the Caller
+// function does not accept an emit function as its second argument.
+var ErrInvalidEmit = errors.New("function does not accept an emit function")
+
+// isEmitType reports whether typ is a function that takes a single event and
+// returns nothing, which allows Caller functions to produce results through
+// it.
+func isEmitType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Func && typ.NumIn() == 1 && typ.NumOut() == 0 && !typ.IsVariadic()
+}
+
+// noopEmit returns an emit function of the given type that discards events.
+// It is passed to functions called without a sink.
+func noopEmit(typ reflect.Type) reflect.Value {
+	return reflect.MakeFunc(typ, func([]reflect.Value) []reflect.Value { return nil })
+}
+
+// CallEmit works like Call for functions shaped as func(req Req, emit
+// func(Event)). The function is given an emit closure that marshals every
+// event to JSON and passes it to sink. Emitting is safe from multiple
+// goroutines, sink calls are serialized. Once sink or marshalling fails, the
+// following events are dropped and the failure is returned unless the
+// function itself returns an error. Functions called with Call get an emit
+// closure that discards events.
+func (c *Caller) CallEmit(data []byte, sink func([]byte) error) error {
+	if !c.withEmit {
+		return ErrInvalidEmit
+	}
+
+	var (
+		mux     sync.Mutex
+		sinkErr error
+	)
+	emit := reflect.MakeFunc(c.metatyp, func(args []reflect.Value) []reflect.Value {
+		mux.Lock()
+		defer mux.Unlock()
+		if sinkErr != nil {
+			return nil
+		}
+		b, err := json.Marshal(args[0].Interface())
+		if err == nil {
+			err = sink(b)
+		}
+		sinkErr = err
+		return nil
+	})
+
+	if err := c.callMeta(context.Background(), data, emit); err != nil {
+		return err
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	return sinkErr
+}
@@ -0,0 +1,91 @@
+package caller
+
+import (
+	"errors"
+	"testing"
+)
+
+type testEmitEvent struct {
+	Seq  int    `json:"seq"`
+	Body string `json:"body"`
+}
+
+func testEmitFun(m testMessage, emit func(testEmitEvent)) {
+	for i := 1; i <= 3; i++ {
+		emit(testEmitEvent{Seq: i, Body: m.Body})
+	}
+}
+
+func TestCallEmit(t *testing.T) {
+	c, err := New(testEmitFun)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got []string
+	err = c.CallEmit([]byte(testPayload), func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := []string{
+		`{"seq":1,"body":"Success!"}`,
+		`{"seq":2,"body":"Success!"}`,
+		`{"seq":3,"body":"Success!"}`,
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("Expected %d events, got %d", len(exp), len(got))
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("Expected event %d to be %s, got %s", i, exp[i], got[i])
+		}
+	}
+}
+
+func TestCallEmitSinkError(t *testing.T) {
+	c, _ := New(testEmitFun)
+	sinkErr := errors.New("sink is full")
+
+	var calls int
+	err := c.CallEmit([]byte(testPayload), func(b []byte) error {
+		calls++
+		return sinkErr
+	})
+	if err != sinkErr {
+		t.Errorf("Expected sink error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected events after a failure to be dropped, got %d sink calls", calls)
+	}
+}
+
+func TestCallEmitWithoutSink(t *testing.T) {
+	c, _ := New(testEmitFun)
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected events to be discarded, got %v", err)
+	}
+}
+
+func TestCallEmitInvalid(t *testing.T) {
+	c, _ := New(testFun)
+	err := c.CallEmit([]byte(testPayload), func(b []byte) error { return nil })
+	if err != ErrInvalidEmit {
+		t.Errorf("Expected ErrInvalidEmit, got %v", err)
+	}
+}
+
+func TestNewCallerWithInvalidEmit(t *testing.T) {
+	for _, fun := range []interface{}{
+		func(m testMessage, emit func(a, b int)) {},
+		func(m testMessage, emit func(int) error) {},
+		func(m testMessage, emit func(...int)) {},
+	} {
+		if _, err := New(fun); err != ErrInvalidFunctionInArguments {
+			t.Errorf("Expected ErrInvalidFunctionInArguments for %T, got %v", fun, err)
+		}
+	}
+}
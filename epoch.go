@@ -0,0 +1,95 @@
+package caller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// collectTimeFields returns top-level struct fields of the argument type that
+// hold a time.Time or a pointer to one.
+func collectTimeFields(typ reflect.Type) []reflect.StructField {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var res []reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		ftyp := f.Type
+		if ftyp.Kind() == reflect.Ptr {
+			ftyp = ftyp.Elem()
+		}
+		if ftyp == timeType && f.PkgPath == "" {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// convertEpochTimes rewrites JSON numbers found in the given time fields of
+// an object into RFC 3339 strings that time.Time can be unmarshalled from.
+// Numbers are interpreted as the number of units elapsed since the Unix
+// epoch. Data that is not an object, or has no numeric time fields, is
+// returned as is.
+func convertEpochTimes(data []byte, fields []reflect.StructField, key string, unit time.Duration) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return data, nil
+	}
+
+	var changed bool
+	for _, f := range fields {
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		for k, raw := range obj {
+			if !strings.EqualFold(k, name) {
+				continue
+			}
+			raw = bytes.TrimSpace(raw)
+			if len(raw) == 0 || (raw[0] != '-' && (raw[0] < '0' || raw[0] > '9')) {
+				continue
+			}
+			t, err := epochTime(json.Number(raw), unit)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", name, err)
+			}
+			if obj[k], err = json.Marshal(t); err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(obj)
+}
+
+func epochTime(n json.Number, unit time.Duration) (time.Time, error) {
+	if i, err := n.Int64(); err == nil && unit <= time.Second && time.Second%unit == 0 {
+		perSec := int64(time.Second / unit)
+		return time.Unix(i/perSec, (i%perSec)*int64(unit)).UTC(), nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, frac := math.Modf(f * float64(unit) / float64(time.Second))
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))).UTC(), nil
+}
@@ -0,0 +1,60 @@
+package caller
+
+import (
+	"testing"
+	"time"
+)
+
+type testEpochMessage struct {
+	Created time.Time  `json:"created"`
+	Updated *time.Time `json:"updated"`
+	Body    string     `json:"body"`
+}
+
+func TestCallWithEpochSeconds(t *testing.T) {
+	var got testEpochMessage
+	c, _ := New(func(m testEpochMessage) { got = m })
+	c.EpochUnit = time.Second
+
+	payload := `{"created":1700000000,"updated":1700000000.5,"body":"Success!"}`
+	if err := c.Call([]byte(payload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	exp := time.Unix(1700000000, 0)
+	if !got.Created.Equal(exp) {
+		t.Errorf("Expected created to be %v, got %v", exp, got.Created)
+	}
+	if got.Updated == nil || !got.Updated.Equal(exp.Add(500*time.Millisecond)) {
+		t.Errorf("Expected updated to be %v, got %v", exp.Add(500*time.Millisecond), got.Updated)
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallWithEpochMillis(t *testing.T) {
+	var got testEpochMessage
+	c, _ := New(func(m testEpochMessage) { got = m })
+	c.EpochUnit = time.Millisecond
+
+	if err := c.Call([]byte(`{"created":1700000000123}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	exp := time.Unix(1700000000, 123*int64(time.Millisecond))
+	if !got.Created.Equal(exp) {
+		t.Errorf("Expected created to be %v, got %v", exp, got.Created)
+	}
+}
+
+func TestCallWithEpochAndStringTime(t *testing.T) {
+	var got testEpochMessage
+	c, _ := New(func(m testEpochMessage) { got = m })
+	c.EpochUnit = time.Second
+
+	if err := c.Call([]byte(`{"created":"2023-11-14T22:13:20Z"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !got.Created.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected string times to still be accepted, got %v", got.Created)
+	}
+}
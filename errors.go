@@ -0,0 +1,46 @@
+package caller
+
+const defaultMaxErrorPayload = 256
+
+// PayloadError is an error that carries the payload that caused it. It is
+// returned by Call when IncludePayloadInError is set.
+type PayloadError struct {
+	Err     error
+	payload []byte
+}
+
+// Error returns the message of the underlying error.
+func (e *PayloadError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// Payload returns the payload that caused the error, possibly truncated.
+func (e *PayloadError) Payload() []byte {
+	return e.payload
+}
+
+func (c *Caller) wrapError(err error, data []byte) error {
+	if err == nil {
+		return nil
+	}
+	if c.IncludePayloadInError {
+		max := c.MaxErrorPayload
+		if max <= 0 {
+			max = defaultMaxErrorPayload
+		}
+		payload := data
+		if len(payload) > max {
+			payload = payload[:max]
+		}
+		err = &PayloadError{Err: err, payload: append([]byte(nil), payload...)}
+	}
+	if c.ErrorWrapper == nil {
+		return err
+	}
+	return c.ErrorWrapper(err, data)
+}
@@ -0,0 +1,61 @@
+package caller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIncludePayloadInError(t *testing.T) {
+	c, _ := New(testFunSilent)
+
+	var perr *PayloadError
+	if err := c.Call([]byte(`{"body":`)); errors.As(err, &perr) {
+		t.Error("Expected payload not to be included by default")
+	}
+
+	c.IncludePayloadInError = true
+	c.MaxErrorPayload = 4
+	err := c.Call([]byte(`{"body":`))
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected PayloadError, got: %v", err)
+	}
+	if string(perr.Payload()) != `{"bo` {
+		t.Errorf("Expected truncated payload %q, got %q", `{"bo`, perr.Payload())
+	}
+}
+
+func TestIncludePayloadInErrorWrapperGetsFullPayload(t *testing.T) {
+	c, _ := New(testFunSilent)
+	c.IncludePayloadInError = true
+	c.MaxErrorPayload = 2
+
+	var size int
+	c.ErrorWrapper = func(err error, data []byte) error {
+		size = len(data)
+		return err
+	}
+	payload := []byte(`{"body":`)
+	if err := c.Call(payload); err == nil {
+		t.Fatal("Expected syntax error, got nil")
+	}
+	if size != len(payload) {
+		t.Errorf("Expected wrapper to get %d bytes, got %d", len(payload), size)
+	}
+}
+
+func TestErrorWrapper(t *testing.T) {
+	c, _ := New(testFunSilent)
+	c.ErrorWrapper = func(err error, data []byte) error {
+		return fmt.Errorf("payload of %d bytes: %w", len(data), err)
+	}
+
+	err := c.Call([]byte("{"))
+	if err == nil {
+		t.Fatal("Expected unmarshalling error, got nil")
+	}
+	if !strings.HasPrefix(err.Error(), "payload of 1 bytes: ") {
+		t.Errorf("Expected error to be wrapped, got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package caller
+
+import (
+	"reflect"
+)
+
+// FieldInfo describes a single field of the Caller function's argument type.
+type FieldInfo struct {
+	// Name is the name of the field in the payload.
+	Name string
+	// Type is the Go type of the field.
+	Type reflect.Type
+	// Required is true for fields tagged with `required:"true"`.
+	Required bool
+	// Default is the value of the `default` tag, if any.
+	Default string
+}
+
+// Fields returns descriptions of top-level fields of the Caller function's
+// argument type, which is useful for generating documentation of handler
+// inputs. Field names are read from `json` struct tags, or from TagKey if it
+// is set. Fields of embedded structs are promoted. For non-struct argument
+// types Fields returns nil.
+func (c *Caller) Fields() []FieldInfo {
+	typ := c.argtyp
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	return collectFields(typ, c.tagKey())
+}
+
+func collectFields(typ reflect.Type, key string) []FieldInfo {
+	var res []FieldInfo
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			res = append(res, collectFields(f.Type, key)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		res = append(res, FieldInfo{
+			Name:     name,
+			Type:     f.Type,
+			Required: f.Tag.Get("required") == "true",
+			Default:  f.Tag.Get("default"),
+		})
+	}
+	return res
+}
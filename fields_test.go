@@ -0,0 +1,48 @@
+package caller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	c, _ := New(testFun)
+	fields := c.Fields()
+	if len(fields) != 1 {
+		t.Fatalf("Expected 1 field, got %d", len(fields))
+	}
+	exp := FieldInfo{Name: "body", Type: reflect.TypeOf("")}
+	if fields[0] != exp {
+		t.Errorf("Expected field info to be %+v, got %+v", exp, fields[0])
+	}
+}
+
+func TestFieldsTags(t *testing.T) {
+	type base struct {
+		ID int `json:"id" required:"true"`
+	}
+	type msg struct {
+		base
+		Name    string `json:"name,omitempty" default:"anonymous"`
+		Skipped string `json:"-"`
+		Plain   bool
+		private int
+	}
+	c, _ := New(func(m msg) {})
+
+	exp := []FieldInfo{
+		{Name: "id", Type: reflect.TypeOf(0), Required: true},
+		{Name: "name", Type: reflect.TypeOf(""), Default: "anonymous"},
+		{Name: "Plain", Type: reflect.TypeOf(false)},
+	}
+	if got := c.Fields(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected fields to be %+v, got %+v", exp, got)
+	}
+}
+
+func TestFieldsNonStruct(t *testing.T) {
+	c, _ := New(func(s []string) {})
+	if fields := c.Fields(); fields != nil {
+		t.Errorf("Expected no fields, got %+v", fields)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/localhots/caller
+
+go 1.21
@@ -0,0 +1,24 @@
+package caller
+
+// Handler is implemented by anything that can be called with a payload,
+// Caller included. It makes it possible to wrap Callers with middleware.
+type Handler interface {
+	Call(data []byte) error
+}
+
+// HandlerFunc is an adapter that allows using ordinary functions as handlers.
+type HandlerFunc func(data []byte) error
+
+// Call calls f(data).
+func (f HandlerFunc) Call(data []byte) error {
+	return f(data)
+}
+
+// Chain wraps the handler with the given middleware. The first middleware is
+// the outermost one, so it is the first to receive the payload.
+func Chain(h Handler, mws ...func(Handler) Handler) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
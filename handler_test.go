@@ -0,0 +1,33 @@
+package caller
+
+import (
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) func(Handler) Handler {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(data []byte) error {
+				order = append(order, name)
+				return next.Call(data)
+			})
+		}
+	}
+
+	c, _ := New(func(_ testMessage) { order = append(order, "caller") })
+	h := Chain(c, record("first"), record("second"))
+	if err := h.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := []string{"first", "second", "caller"}
+	if len(order) != len(exp) {
+		t.Fatalf("Expected invocation order %q, got %q", exp, order)
+	}
+	for i := range exp {
+		if order[i] != exp[i] {
+			t.Fatalf("Expected invocation order %q, got %q", exp, order)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/localhots/caller/json5
+
+go 1.21
+
+require (
+	github.com/localhots/caller v0.0.0
+	github.com/yosuke-furukawa/json5 v0.1.1
+)
+
+replace github.com/localhots/caller => ../
@@ -0,0 +1,18 @@
+// Package json5 provides an unmarshaller for JSON5 payloads, which tolerates
+// comments, trailing commas and other relaxations common in hand-edited
+// messages. It is kept in its own package so that the dependency is only
+// pulled in when it is used.
+//
+//	c, _ := caller.New(fun)
+//	c.Unmarshaller = json5.Unmarshal
+package json5
+
+import (
+	"github.com/yosuke-furukawa/json5/encoding/json5"
+)
+
+// Unmarshal is an unmarshaller function that decodes JSON5 data into v. Field
+// names are read from `json` struct tags.
+func Unmarshal(data []byte, v interface{}) error {
+	return json5.Unmarshal(data, v)
+}
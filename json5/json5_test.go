@@ -0,0 +1,37 @@
+package json5
+
+import (
+	"testing"
+
+	"github.com/localhots/caller"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+func TestCall(t *testing.T) {
+	var got testMessage
+	c, _ := caller.New(func(m testMessage) { got = m })
+	c.Unmarshaller = Unmarshal
+
+	payload := `{
+		// Edited by hand
+		"body": "Success!",
+	}`
+	if err := c.Call([]byte(payload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallFailure(t *testing.T) {
+	c, _ := caller.New(func(_ testMessage) {})
+	c.Unmarshaller = Unmarshal
+
+	if err := c.Call([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
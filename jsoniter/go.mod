@@ -0,0 +1,15 @@
+module github.com/localhots/caller/jsoniter
+
+go 1.21
+
+require (
+	github.com/json-iterator/go v1.1.12
+	github.com/localhots/caller v0.0.0
+)
+
+require (
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+)
+
+replace github.com/localhots/caller => ../
@@ -0,0 +1,19 @@
+// Package jsoniter provides an unmarshaller backed by json-iterator, which is
+// faster than encoding/json for some workloads. It is kept in its own package
+// so that the dependency is only pulled in when it is used.
+//
+//	c, _ := caller.New(fun)
+//	c.Unmarshaller = jsoniter.Unmarshal
+package jsoniter
+
+import (
+	jsoniter "github.com/json-iterator/go"
+)
+
+var api = jsoniter.ConfigFastest
+
+// Unmarshal is an unmarshaller function that decodes JSON data into v using
+// the fastest json-iterator configuration.
+func Unmarshal(data []byte, v interface{}) error {
+	return api.Unmarshal(data, v)
+}
@@ -0,0 +1,60 @@
+package jsoniter
+
+import (
+	"testing"
+
+	"github.com/localhots/caller"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+const testPayload = `{"body":"Success!"}`
+
+func testFunSilent(_ testMessage) {}
+
+func TestCall(t *testing.T) {
+	var got testMessage
+	c, _ := caller.New(func(m testMessage) { got = m })
+	c.Unmarshaller = Unmarshal
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallFailure(t *testing.T) {
+	c, _ := caller.New(testFunSilent)
+	c.Unmarshaller = Unmarshal
+
+	if err := c.Call([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
+
+//
+// Benchmarks
+//
+
+func BenchmarkCallerStdlib(b *testing.B) {
+	c, _ := caller.New(testFunSilent)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		c.Call([]byte(testPayload))
+	}
+}
+
+func BenchmarkCallerJsoniter(b *testing.B) {
+	c, _ := caller.New(testFunSilent)
+	c.Unmarshaller = Unmarshal
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		c.Call([]byte(testPayload))
+	}
+}
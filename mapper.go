@@ -0,0 +1,150 @@
+package caller
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// assignValue populates dst with src, which holds generic values like the
+// ones produced by unmarshalling JSON into an interface{}: maps with string
+// keys, slices, strings, numbers, booleans and nils. Struct field names are
+// read from the given tag key.
+func assignValue(dst reflect.Value, src interface{}, key string) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	sval := reflect.ValueOf(src)
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(dst.Type().Elem())
+		if err := assignValue(ptr.Elem(), src, key); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	case reflect.Interface:
+		if sval.Type().AssignableTo(dst.Type()) {
+			dst.Set(sval)
+			return nil
+		}
+	case reflect.Struct:
+		if m, ok := src.(map[string]interface{}); ok {
+			return assignFields(dst, m, key)
+		}
+	case reflect.Map:
+		m, ok := src.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			break
+		}
+		res := reflect.MakeMapWithSize(dst.Type(), len(m))
+		for k, v := range m {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignValue(elem, v, key); err != nil {
+				return err
+			}
+			res.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(res)
+		return nil
+	case reflect.Slice:
+		if sval.Kind() != reflect.Slice {
+			break
+		}
+		res := reflect.MakeSlice(dst.Type(), sval.Len(), sval.Len())
+		for i := 0; i < sval.Len(); i++ {
+			if err := assignValue(res.Index(i), sval.Index(i).Interface(), key); err != nil {
+				return err
+			}
+		}
+		dst.Set(res)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := toFloat(sval); ok && f == math.Trunc(f) && f >= -(1<<63) && f < 1<<63 && !dst.OverflowInt(int64(f)) {
+			dst.SetInt(int64(f))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if f, ok := toFloat(sval); ok && f == math.Trunc(f) && f >= 0 && f < 1<<64 && !dst.OverflowUint(uint64(f)) {
+			dst.SetUint(uint64(f))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(sval); ok && !dst.OverflowFloat(f) {
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.String, reflect.Bool:
+		if sval.Kind() == dst.Kind() {
+			dst.Set(sval.Convert(dst.Type()))
+			return nil
+		}
+	}
+
+	if sval.Type().AssignableTo(dst.Type()) {
+		dst.Set(sval)
+		return nil
+	}
+	return fmt.Errorf("caller: cannot assign %T to %s", src, dst.Type())
+}
+
+func assignFields(dst reflect.Value, m map[string]interface{}, key string) error {
+	typ := dst.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fval := dst.Field(i)
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			if err := assignFields(fval, m, key); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fval.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		v, ok := lookupMapField(m, name)
+		if !ok {
+			continue
+		}
+		if err := assignValue(fval, v, key); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// lookupMapField works just like lookupField but for generic maps.
+func lookupMapField(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
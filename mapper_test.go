@@ -0,0 +1,162 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testMappedMessage struct {
+	Body   string            `json:"body"`
+	Count  int8              `json:"count"`
+	Ratio  float32           `json:"ratio"`
+	Flags  []bool            `json:"flags"`
+	Labels map[string]string `json:"labels"`
+	Inner  *testMessage      `json:"inner"`
+	Any    interface{}       `json:"any"`
+	Skip   string            `json:"-"`
+}
+
+func TestAssignValue(t *testing.T) {
+	src := map[string]interface{}{
+		"body":   "Success!",
+		"count":  float64(3),
+		"ratio":  0.5,
+		"flags":  []interface{}{true, false},
+		"labels": map[string]interface{}{"a": "b"},
+		"inner":  map[string]interface{}{"body": "Inner!"},
+		"any":    []interface{}{1.0},
+		"Skip":   "no",
+	}
+
+	var m testMappedMessage
+	if err := assignValue(reflect.ValueOf(&m).Elem(), src, "json"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if m.Body != "Success!" || m.Count != 3 || m.Ratio != 0.5 || m.Skip != "" {
+		t.Errorf("Unexpected scalar fields: %+v", m)
+	}
+	if len(m.Flags) != 2 || !m.Flags[0] || m.Flags[1] {
+		t.Errorf("Expected flags [true false], got %v", m.Flags)
+	}
+	if m.Labels["a"] != "b" {
+		t.Errorf("Expected labels map[a:b], got %v", m.Labels)
+	}
+	if m.Inner == nil || m.Inner.Body != "Inner!" {
+		t.Errorf("Expected inner body %q, got %+v", "Inner!", m.Inner)
+	}
+	if generic, ok := m.Any.([]interface{}); !ok || len(generic) != 1 {
+		t.Errorf("Expected generic value to be assigned as is, got %#v", m.Any)
+	}
+}
+
+func TestAssignValueFailure(t *testing.T) {
+	cases := []map[string]interface{}{
+		{"body": 1.0},
+		{"count": 1.5},
+		{"count": 300.0},
+		{"flags": "true"},
+	}
+	for _, src := range cases {
+		var m testMappedMessage
+		if err := assignValue(reflect.ValueOf(&m).Elem(), src, "json"); err == nil {
+			t.Errorf("Expected an error for %v, got nil", src)
+		}
+	}
+}
+
+func TestAssignValueFloatOutOfRange(t *testing.T) {
+	type numbers struct {
+		N int64  `json:"n"`
+		U uint64 `json:"u"`
+	}
+	cases := []map[string]interface{}{
+		{"n": 1e20},
+		{"n": -1e20},
+		{"n": float64(1 << 63)},
+		{"u": 1e20},
+		{"u": float64(1 << 64)},
+		{"u": -1.0},
+	}
+	for _, src := range cases {
+		var m numbers
+		if err := assignValue(reflect.ValueOf(&m).Elem(), src, "json"); err == nil {
+			t.Errorf("Expected an error for %v, got nil with %+v", src, m)
+		}
+	}
+
+	var m numbers
+	src := map[string]interface{}{"n": float64(-1 << 63), "u": float64(1 << 63)}
+	if err := assignValue(reflect.ValueOf(&m).Elem(), src, "json"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if m.N != -1<<63 || m.U != 1<<63 {
+		t.Errorf("Expected boundary values to be assigned, got %+v", m)
+	}
+}
+
+func TestCallMap(t *testing.T) {
+	var got testMessage
+	c, _ := New(func(m testMessage) { got = m })
+
+	if err := c.CallMap(map[string]interface{}{"body": "Success!"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+	if err := c.CallMap(map[string]interface{}{"body": 1}); err == nil {
+		t.Error("Expected assignment error, got nil")
+	}
+}
+
+func TestCallMapOptions(t *testing.T) {
+	type msg struct {
+		Title string `json:"title" aliases:"name" required:"true"`
+		Note  string `json:"note"`
+	}
+	var calls int
+	var got msg
+	c, _ := New(func(m msg) {
+		calls++
+		got = m
+	})
+	c.EnforceRequired = true
+	c.ResolveAliases = true
+	c.Template = msg{Note: "default"}
+
+	if err := c.CallMap(map[string]interface{}{}); !errors.Is(err, ErrMissingRequiredField) {
+		t.Errorf("Expected ErrMissingRequiredField, got: %v", err)
+	}
+	src := map[string]interface{}{"name": "old"}
+	if err := c.CallMap(src); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Title != "old" || got.Note != "default" {
+		t.Errorf("Expected alias and template to be applied, got %+v", got)
+	}
+	if _, ok := src["title"]; ok {
+		t.Errorf("Expected source map to stay intact, got %v", src)
+	}
+
+	c.DryRun = true
+	if err := c.CallMap(src); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected function not to be called in dry run mode, got %d calls", calls)
+	}
+}
+
+func TestCallMapListeners(t *testing.T) {
+	c, _ := New(testFunSilent)
+	var values []interface{}
+	c.Listeners = append(c.Listeners, func(v interface{}, err error) { values = append(values, v) })
+
+	if err := c.CallMap(map[string]interface{}{"body": "Success!"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(values) != 1 || values[0] != (testMessage{Body: "Success!"}) {
+		t.Errorf("Expected listener to get the decoded value, got %v", values)
+	}
+}
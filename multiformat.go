@@ -0,0 +1,91 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+)
+
+// ErrNoUnmarshallers is an error that is returned by an unmarshaller created
+// with MultiFormat when it was given no unmarshallers to try.
+var ErrNoUnmarshallers = errors.New("no unmarshallers to try")
+
+// MultiFormat returns an unmarshaller function that tries each of the given
+// unmarshallers in order until one succeeds, and returns the last error if
+// all of them fail. The target value is restored to its state before the
+// first attempt after each failed one, so values set by Template, NewValue or
+// AllocPointers are kept. To avoid repeating failed attempts, it remembers which
+// unmarshaller worked for payloads starting with the same byte and tries it
+// first next time.
+func MultiFormat(unmarshallers ...func(data []byte, v interface{}) error) func(data []byte, v interface{}) error {
+	// Index of the last unmarshaller that succeeded, plus one, keyed by the
+	// first meaningful byte of a payload
+	var hints [256]int32
+
+	return func(data []byte, v interface{}) error {
+		if len(unmarshallers) == 0 {
+			return ErrNoUnmarshallers
+		}
+
+		shape := payloadShape(data)
+		hint := int(atomic.LoadInt32(&hints[shape])) - 1
+		var (
+			err  error
+			snap reflect.Value
+		)
+		if len(unmarshallers) > 1 {
+			snap = snapshotValue(v)
+		}
+		for i := -1; i < len(unmarshallers); i++ {
+			idx := i
+			if i == -1 {
+				if idx = hint; idx == -1 {
+					continue
+				}
+			} else if i == hint {
+				continue
+			}
+
+			if err = unmarshallers[idx](data, v); err == nil {
+				if idx != hint {
+					atomic.StoreInt32(&hints[shape], int32(idx+1))
+				}
+				return nil
+			}
+			restoreValue(v, snap)
+		}
+		return err
+	}
+}
+
+// payloadShape returns the first byte of the payload that is not whitespace.
+func payloadShape(data []byte) byte {
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return b
+		}
+	}
+	return 0
+}
+
+// snapshotValue returns a deep copy of the value v points to, or an invalid
+// value if v is not a pointer.
+func snapshotValue(v interface{}) reflect.Value {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return reflect.Value{}
+	}
+	snap := reflect.New(val.Elem().Type()).Elem()
+	deepCopy(snap, val.Elem())
+	return snap
+}
+
+// restoreValue copies the snapshot back into the value v points to. It is
+// copied deeply so that the next attempt can not modify the snapshot.
+func restoreValue(v interface{}, snap reflect.Value) {
+	if snap.IsValid() {
+		deepCopy(reflect.ValueOf(v).Elem(), snap)
+	}
+}
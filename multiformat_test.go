@@ -0,0 +1,85 @@
+package caller
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var errNotMsgpack = errors.New("not msgpack")
+
+func TestMultiFormat(t *testing.T) {
+	var msgpackCalls, jsonCalls int
+	msgpack := func(data []byte, v interface{}) error {
+		msgpackCalls++
+		v.(*testMessage).Body = "garbage"
+		return errNotMsgpack
+	}
+	jsonu := func(data []byte, v interface{}) error {
+		jsonCalls++
+		return json.Unmarshal(data, v)
+	}
+
+	unmarshal := MultiFormat(msgpack, jsonu)
+	for i := 0; i < 2; i++ {
+		var m testMessage
+		if err := unmarshal([]byte(testPayload), &m); err != nil {
+			t.Fatal(err.Error())
+		}
+		if m.Body != "Success!" {
+			t.Errorf("Expected body to be %q, got %q", "Success!", m.Body)
+		}
+	}
+	if msgpackCalls != 1 || jsonCalls != 2 {
+		t.Errorf("Expected the working unmarshaller to be tried first on repeat, got %d msgpack and %d json calls",
+			msgpackCalls, jsonCalls)
+	}
+}
+
+func TestMultiFormatFailure(t *testing.T) {
+	msgpack := func(data []byte, v interface{}) error { return errNotMsgpack }
+
+	var m testMessage
+	err := MultiFormat(json.Unmarshal, msgpack)([]byte("{"), &m)
+	if err != errNotMsgpack {
+		t.Errorf("Expected the last error, got: %v", err)
+	}
+	if err := MultiFormat()([]byte(testPayload), &m); err != ErrNoUnmarshallers {
+		t.Errorf("Expected ErrNoUnmarshallers, got: %v", err)
+	}
+}
+
+func TestCallWithMultiFormat(t *testing.T) {
+	var got testMessage
+	c, _ := New(func(m testMessage) { got = m })
+	c.Unmarshaller = MultiFormat(func([]byte, interface{}) error { return errNotMsgpack }, json.Unmarshal)
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
+
+func TestCallWithMultiFormatTemplate(t *testing.T) {
+	type msg struct {
+		Body  string `json:"body"`
+		Topic string `json:"topic"`
+	}
+	var got msg
+	c, _ := New(func(m msg) { got = m })
+	c.Template = msg{Body: "default", Topic: "default"}
+	c.Unmarshaller = MultiFormat(func(data []byte, v interface{}) error {
+		v.(*msg).Body = "garbage"
+		return errNotMsgpack
+	}, json.Unmarshal)
+
+	if err := c.Call([]byte(`{"topic":"orders"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	exp := msg{Body: "default", Topic: "orders"}
+	if got != exp {
+		t.Errorf("Expected template values to survive a failed attempt, got %+v", got)
+	}
+}
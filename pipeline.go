@@ -0,0 +1,55 @@
+package caller
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrEmptyPipeline is an error that is returned by the NewPipeline
+	// function when it is given no Callers.
+	ErrEmptyPipeline = errors.New("pipeline must have at least one caller")
+	// ErrPipelineArgTypeMismatch is an error that is returned by the
+	// NewPipeline function when its Callers' functions accept different
+	// argument types.
+	ErrPipelineArgTypeMismatch = errors.New("pipeline callers must accept the same argument type")
+)
+
+// Pipeline runs a sequence of Callers sharing the same argument type on a
+// single decoded value.
+type Pipeline struct {
+	callers []*Caller
+}
+
+// NewPipeline creates a new Pipeline instance out of the given Callers. All
+// of them must accept the same argument type.
+func NewPipeline(callers ...*Caller) (*Pipeline, error) {
+	if len(callers) == 0 {
+		return nil, ErrEmptyPipeline
+	}
+	for _, c := range callers[1:] {
+		if c.argtyp != callers[0].argtyp {
+			return nil, ErrPipelineArgTypeMismatch
+		}
+	}
+
+	return &Pipeline{callers: callers}, nil
+}
+
+// Run unmarshals the payload once using the first Caller and then calls every
+// Caller's function with the value in order, stopping at the first error.
+// Functions accepting a pointer see changes made by the preceding ones.
+func (p *Pipeline) Run(data []byte) error {
+	first := p.callers[0]
+	val, err := first.unmarshal(data)
+	if err != nil {
+		return first.wrapError(err, data)
+	}
+
+	for _, c := range p.callers {
+		if err := c.invoke(context.Background(), val, c.defaultMeta(data)); err != nil {
+			return c.wrapError(err, data)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package caller
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	errFailed := errors.New("failed")
+	var order []string
+	c1, _ := New(func(m *testMessage) {
+		order = append(order, "first")
+		m.Body += " Modified!"
+	})
+	c2, _ := New(func(m *testMessage) error {
+		order = append(order, "second:"+m.Body)
+		return errFailed
+	})
+	c3, _ := New(func(_ *testMessage) { order = append(order, "third") })
+
+	p, err := NewPipeline(c1, c2, c3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := p.Run([]byte(testPayload)); err != errFailed {
+		t.Errorf("Expected second function's error, got: %v", err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second:Success! Modified!" {
+		t.Errorf("Expected pipeline to stop after the second function, got %q", order)
+	}
+}
+
+func TestPipelineWithRawPayload(t *testing.T) {
+	var raw string
+	c1, _ := New(func(_ testMessage) {})
+	c2, _ := New(func(_ testMessage, data []byte) { raw = string(data) })
+	p, _ := NewPipeline(c1, c2)
+
+	if err := p.Run([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if raw != testPayload {
+		t.Errorf("Expected raw payload %q, got %q", testPayload, raw)
+	}
+}
+
+func TestPipelineDecodeFailure(t *testing.T) {
+	c, _ := New(testFunSilent)
+	p, _ := NewPipeline(c)
+
+	if err := p.Run([]byte("{")); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
+
+func TestNewPipelineArgTypeMismatch(t *testing.T) {
+	c1, _ := New(testFunSilent)
+	c2, _ := New(func(_ *testMessage) {})
+
+	if _, err := NewPipeline(c1, c2); err != ErrPipelineArgTypeMismatch {
+		t.Errorf("Expected ErrPipelineArgTypeMismatch, got: %v", err)
+	}
+	if _, err := NewPipeline(); err != ErrEmptyPipeline {
+		t.Errorf("Expected ErrEmptyPipeline, got: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/localhots/caller/proto
+
+go 1.23
+
+require (
+	github.com/localhots/caller v0.0.0
+	google.golang.org/protobuf v1.36.12
+)
+
+replace github.com/localhots/caller => ../
@@ -0,0 +1,77 @@
+// Package proto wires protojson decoding into callers. Unlike encoding/json,
+// protojson follows the canonical JSON mapping of Protocol Buffers, which
+// correctly handles well-known types like Timestamp and Duration as well as
+// oneof fields.
+package proto
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/localhots/caller"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrNotProtoMessage is an error that is returned by Unmarshal when the
+	// target value is not a proto message.
+	ErrNotProtoMessage = errors.New("argument must be a proto message")
+	// ErrPayloadTooLarge is an error that is returned by unmarshallers created
+	// with UnmarshalLimit when the payload exceeds the size limit.
+	ErrPayloadTooLarge = errors.New("payload is too large")
+)
+
+// New creates a new Caller instance just like caller.New does and makes it
+// use protojson to unmarshal payloads. The function is expected to accept
+// a generated proto message, preferably as a pointer.
+func New(fun interface{}) (*caller.Caller, error) {
+	c, err := caller.New(fun)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Unmarshaller = Unmarshal
+	return c, nil
+}
+
+// Unmarshal is an unmarshaller function that decodes protojson data into v.
+// It supports both pointers to messages and pointers to message pointers,
+// the latter being what a Caller allocates for functions that accept a
+// message pointer.
+func Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Unmarshal(data, m)
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Ptr {
+		return ErrNotProtoMessage
+	}
+	ptr := reflect.New(val.Elem().Type().Elem())
+	m, ok := ptr.Interface().(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	if err := protojson.Unmarshal(data, m); err != nil {
+		return err
+	}
+	val.Elem().Set(ptr)
+	return nil
+}
+
+// UnmarshalLimit returns an unmarshaller function that works like Unmarshal
+// but rejects payloads larger than limit bytes with ErrPayloadTooLarge before
+// decoding them. It guards against messages that are maliciously large, for
+// example after decompression.
+//
+//	c, _ := proto.New(fun)
+//	c.Unmarshaller = proto.UnmarshalLimit(1 << 20)
+func UnmarshalLimit(limit int) func(data []byte, v interface{}) error {
+	return func(data []byte, v interface{}) error {
+		if len(data) > limit {
+			return ErrPayloadTooLarge
+		}
+		return Unmarshal(data, v)
+	}
+}
@@ -0,0 +1,73 @@
+package proto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCallTimestamp(t *testing.T) {
+	var got *timestamppb.Timestamp
+	c, err := New(func(ts *timestamppb.Timestamp) { got = ts })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := c.Call([]byte(`"2023-11-14T22:13:20Z"`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	exp := time.Unix(1700000000, 0).UTC()
+	if got == nil || !got.AsTime().Equal(exp) {
+		t.Errorf("Expected timestamp to be %v, got %v", exp, got)
+	}
+}
+
+func TestCallDuration(t *testing.T) {
+	var got *durationpb.Duration
+	c, _ := New(func(d *durationpb.Duration) { got = d })
+
+	if err := c.Call([]byte(`"1.5s"`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got == nil || got.AsDuration() != 1500*time.Millisecond {
+		t.Errorf("Expected duration to be 1.5s, got %v", got)
+	}
+}
+
+func TestUnmarshalNonMessage(t *testing.T) {
+	var v struct{ Body string }
+	if err := Unmarshal([]byte(`{}`), &v); err != ErrNotProtoMessage {
+		t.Errorf("Expected ErrNotProtoMessage, got: %v", err)
+	}
+}
+
+func TestCallPayloadTooLarge(t *testing.T) {
+	var called bool
+	c, _ := New(func(ts *timestamppb.Timestamp) { called = true })
+	c.Unmarshaller = UnmarshalLimit(32)
+
+	payload := `"2023-11-14T22:13:20` + strings.Repeat("0", 64) + `Z"`
+	if err := c.Call([]byte(payload)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("Expected ErrPayloadTooLarge, got: %v", err)
+	}
+	if called {
+		t.Error("Expected function not to be called")
+	}
+}
+
+func TestCallPayloadWithinLimit(t *testing.T) {
+	var got *timestamppb.Timestamp
+	c, _ := New(func(ts *timestamppb.Timestamp) { got = ts })
+	c.Unmarshaller = UnmarshalLimit(32)
+
+	if err := c.Call([]byte(`"2023-11-14T22:13:20Z"`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got == nil {
+		t.Error("Expected timestamp to be decoded")
+	}
+}
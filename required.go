@@ -0,0 +1,105 @@
+package caller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMissingRequiredField is an error that is returned by Call when
+// EnforceRequired is set and a field tagged with `required:"true"` is absent
+// from the payload.
+var ErrMissingRequiredField = errors.New("missing required field")
+
+// requiredField is a struct field tagged as required along with the embedded
+// struct fields it is promoted through.
+type requiredField struct {
+	reflect.StructField
+	embedded []reflect.StructField
+}
+
+// collectRequired returns struct fields of the argument type that are tagged
+// as required, including fields of embedded structs. Whether an embedded
+// struct is flattened depends on the tag key, so it is decided by
+// checkRequired.
+func collectRequired(typ reflect.Type) []requiredField {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var res []requiredField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for _, rf := range collectRequired(f.Type) {
+				rf.embedded = append([]reflect.StructField{f}, rf.embedded...)
+				res = append(res, rf)
+			}
+		}
+		if f.PkgPath == "" && f.Tag.Get("required") == "true" {
+			res = append(res, requiredField{StructField: f})
+		}
+	}
+	return res
+}
+
+// checkRequired makes sure that JSON data contains every required field.
+// Presence is all that matters, a field set to a zero value passes the check.
+// Fields of embedded structs are only checked if the structs are promoted,
+// that is have no name under the tag key.
+func checkRequired(data []byte, fields []requiredField, key string) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return checkPresent(fields, key, func(name string) bool {
+		_, ok := lookupField(obj, name)
+		return ok
+	})
+}
+
+// checkRequiredMap works like checkRequired for a map parsed from JSON.
+func checkRequiredMap(m map[string]interface{}, fields []requiredField, key string) error {
+	return checkPresent(fields, key, func(name string) bool {
+		_, ok := lookupMapField(m, name)
+		return ok
+	})
+}
+
+func checkPresent(fields []requiredField, key string, present func(name string) bool) error {
+	for _, f := range fields {
+		if !isPromoted(f.embedded, key) {
+			continue
+		}
+		name, ok := tagName(f.StructField, key)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			// Embedded structs without a name are flattened
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				continue
+			}
+			name = f.Name
+		}
+		if !present(name) {
+			return fmt.Errorf("%w: %s", ErrMissingRequiredField, name)
+		}
+	}
+	return nil
+}
+
+// isPromoted reports whether fields of the innermost of the embedded structs
+// are promoted to the top level under the given tag key.
+func isPromoted(embedded []reflect.StructField, key string) bool {
+	for _, f := range embedded {
+		if name, ok := tagName(f, key); !ok || name != "" {
+			return false
+		}
+	}
+	return true
+}
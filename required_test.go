@@ -0,0 +1,86 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testRequiredMessage struct {
+	Body  string `json:"body" required:"true"`
+	Count int    `json:"count" required:"true"`
+	Note  string `json:"note"`
+}
+
+func TestCallEnforceRequired(t *testing.T) {
+	var calls int
+	c, _ := New(func(_ testRequiredMessage) { calls++ })
+	c.EnforceRequired = true
+
+	if err := c.Call([]byte(`{"body":"","count":0}`)); err != nil {
+		t.Errorf("Expected present zero values to pass, got: %v", err)
+	}
+	err := c.Call([]byte(`{"body":"Success!"}`))
+	if !errors.Is(err, ErrMissingRequiredField) {
+		t.Errorf("Expected ErrMissingRequiredField, got: %v", err)
+	}
+	if err == nil || err.Error() != "missing required field: count" {
+		t.Errorf("Expected error to name the missing field, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one call, got %d", calls)
+	}
+}
+
+func TestCallEnforceRequiredDisabled(t *testing.T) {
+	c, _ := New(func(_ testRequiredMessage) {})
+
+	if err := c.Call([]byte(`{}`)); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+}
+
+func TestCollectRequired(t *testing.T) {
+	type embedded struct {
+		ID string `json:"id" required:"true"`
+	}
+	type message struct {
+		embedded
+		Body    string `required:"true"`
+		private string `required:"true"`
+	}
+
+	fields := collectRequired(reflect.TypeOf(&message{}))
+	if len(fields) != 2 || fields[0].Name != "ID" || fields[1].Name != "Body" {
+		t.Errorf("Expected required fields ID and Body, got %v", fields)
+	}
+}
+
+func TestCallEnforceRequiredEmbeddedTagKey(t *testing.T) {
+	type embedded struct {
+		ID string `json:"id" msgpack:"id" required:"true"`
+	}
+	type flattened struct {
+		embedded `json:"meta"`
+	}
+	type named struct {
+		embedded `msgpack:"meta"`
+	}
+
+	c, _ := New(func(_ flattened) {})
+	c.EnforceRequired = true
+	c.TagKey = "msgpack"
+	if err := c.Call([]byte(`{}`)); !errors.Is(err, ErrMissingRequiredField) {
+		t.Errorf("Expected embedded struct without a msgpack name to be promoted, got: %v", err)
+	}
+	if err := c.Call([]byte(`{"id":"1"}`)); err != nil {
+		t.Errorf("Expected promoted field to be found, got: %v", err)
+	}
+
+	c, _ = New(func(_ named) {})
+	c.EnforceRequired = true
+	c.TagKey = "msgpack"
+	if err := c.Call([]byte(`{"meta":{}}`)); err != nil {
+		t.Errorf("Expected fields of a named embedded struct not to be checked, got: %v", err)
+	}
+}
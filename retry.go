@@ -0,0 +1,19 @@
+package caller
+
+import (
+	"errors"
+)
+
+// Retryable is implemented by errors that know whether the failed operation
+// may succeed if retried. Functions can return such errors to let message
+// consumers choose between requeueing a message and dead-lettering it.
+type Retryable interface {
+	Retryable() bool
+}
+
+// IsRetryable reports whether any error in err's chain implements Retryable
+// and reports itself as retryable.
+func IsRetryable(err error) bool {
+	var r Retryable
+	return errors.As(err, &r) && r.Retryable()
+}
@@ -0,0 +1,28 @@
+package caller
+
+import (
+	"testing"
+)
+
+type testRetryableError bool
+
+func (e testRetryableError) Error() string   { return "temporary failure" }
+func (e testRetryableError) Retryable() bool { return bool(e) }
+
+func TestIsRetryable(t *testing.T) {
+	var ret error
+	c, _ := New(func(_ testMessage) error { return ret })
+	c.AnnotateType = true
+
+	ret = testRetryableError(true)
+	if err := c.Call([]byte(testPayload)); !IsRetryable(err) {
+		t.Errorf("Expected error to be retryable, got: %v", err)
+	}
+	ret = testRetryableError(false)
+	if err := c.Call([]byte(testPayload)); IsRetryable(err) {
+		t.Errorf("Expected error not to be retryable, got: %v", err)
+	}
+	if err := c.Call([]byte("{")); IsRetryable(err) {
+		t.Errorf("Expected unmarshalling error not to be retryable, got: %v", err)
+	}
+}
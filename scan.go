@@ -0,0 +1,156 @@
+package caller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrDuplicateKey is an error that is returned by Call when
+	// RejectDuplicateKeys is set and an object in the payload has the same
+	// key more than once.
+	ErrDuplicateKey = errors.New("duplicate object key")
+	// ErrMaxDepthExceeded is an error that is returned by Call when MaxDepth
+	// is set and the payload has objects or arrays nested deeper than that.
+	ErrMaxDepthExceeded = errors.New("payload is nested too deeply")
+)
+
+// scanner checks JSON data token by token before it is unmarshalled. This
+// allows rejecting adversarial payloads without decoding them.
+type scanner struct {
+	dec        *json.Decoder
+	key        string
+	maxDepth   int
+	rejectDups bool
+}
+
+// scanJSON scans the data and returns an error if it is nested deeper than
+// maxDepth, unless it is zero, or if rejectDups is set and any object at any
+// level contains a duplicate key. Keys are compared exactly.
+func scanJSON(data []byte, maxDepth int, rejectDups bool) error {
+	return scanJSONInto(data, nil, "", maxDepth, rejectDups)
+}
+
+// scanJSONInto works like scanJSON but follows the type the data is decoded
+// into. Keys of objects that decode into structs are compared
+// case-insensitively, like encoding/json matches them to fields, while keys
+// of maps and other objects are compared exactly. Struct field names are read
+// from the given tag key.
+func scanJSONInto(data []byte, typ reflect.Type, key string, maxDepth int, rejectDups bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	s := scanner{dec: dec, key: key, maxDepth: maxDepth, rejectDups: rejectDups}
+	return s.scanValue(0, typ)
+}
+
+func (s *scanner) scanValue(depth int, typ reflect.Type) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	if depth++; s.maxDepth > 0 && depth > s.maxDepth {
+		return fmt.Errorf("%w: limit is %d", ErrMaxDepthExceeded, s.maxDepth)
+	}
+	typ = scanType(typ)
+
+	if tok == json.Delim('{') {
+		fold := typ != nil && typ.Kind() == reflect.Struct
+		keys := map[string]struct{}{}
+		for s.dec.More() {
+			tok, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			if s.rejectDups {
+				cmp := key
+				if fold {
+					cmp = strings.ToLower(strings.ToUpper(key))
+				}
+				if _, ok := keys[cmp]; ok {
+					return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+				}
+				keys[cmp] = struct{}{}
+			}
+
+			var elem reflect.Type
+			switch {
+			case fold:
+				elem = fieldType(typ, key, s.key)
+			case typ != nil && typ.Kind() == reflect.Map:
+				elem = typ.Elem()
+			}
+			if err := s.scanValue(depth, elem); err != nil {
+				return err
+			}
+		}
+	} else {
+		var elem reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elem = typ.Elem()
+		}
+		for s.dec.More() {
+			if err := s.scanValue(depth, elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Consume the closing delimiter
+	_, err = s.dec.Token()
+	return err
+}
+
+// scanType dereferences pointers and returns nil for types whose decoding can
+// not be followed, like interfaces and custom unmarshallers.
+func scanType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() == reflect.Interface || reflect.PtrTo(typ).Implements(unmarshalerType) {
+		return nil
+	}
+	return typ
+}
+
+// fieldType returns the type of a struct field matching the name, preferring
+// an exact match but accepting a case-insensitive one. Fields of embedded
+// structs are promoted. It returns nil if there is no such field.
+func fieldType(typ reflect.Type, name, key string) reflect.Type {
+	var folded reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fname, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && fname == "" {
+			if etyp := scanType(f.Type); etyp != nil && etyp.Kind() == reflect.Struct {
+				if ftyp := fieldType(etyp, name, key); ftyp != nil {
+					return ftyp
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if fname == "" {
+			fname = f.Name
+		}
+		if fname == name {
+			return f.Type
+		}
+		if folded == nil && strings.EqualFold(fname, name) {
+			folded = f.Type
+		}
+	}
+	return folded
+}
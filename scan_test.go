@@ -0,0 +1,136 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanDuplicateKeys(t *testing.T) {
+	valid := []string{
+		testPayload,
+		`[{"a":1},{"a":2}]`,
+		`{"a":{"a":1},"b":[{"a":1,"b":2}]}`,
+		`"a"`,
+		`{"a":1,"A":2}`,
+	}
+	for _, payload := range valid {
+		if err := scanJSON([]byte(payload), 0, true); err != nil {
+			t.Errorf("Expected no error for %s, got: %v", payload, err)
+		}
+	}
+
+	invalid := []string{
+		`{"a":1,"a":2}`,
+		`{"a":{"b":1,"b":2}}`,
+		`[{"a":1,"a":2}]`,
+	}
+	for _, payload := range invalid {
+		if err := scanJSON([]byte(payload), 0, true); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("Expected ErrDuplicateKey for %s, got: %v", payload, err)
+		}
+	}
+}
+
+func TestCallRejectDuplicateKeys(t *testing.T) {
+	var called bool
+	c, _ := New(func(_ testMessage) { called = true })
+	c.RejectDuplicateKeys = true
+
+	err := c.Call([]byte(`{"body":"Success!","body":"Injected!"}`))
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Expected ErrDuplicateKey, got: %v", err)
+	}
+	if called {
+		t.Error("Expected function not to be called")
+	}
+}
+
+func TestCallRejectCaseVariantDuplicateKeys(t *testing.T) {
+	var got testMessage
+	c, _ := New(func(m testMessage) { got = m })
+	c.RejectDuplicateKeys = true
+
+	err := c.Call([]byte(`{"body":"safe","BODY":"evil"}`))
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Expected ErrDuplicateKey, got: %v", err)
+	}
+	if got.Body != "" {
+		t.Errorf("Expected function not to be called, got body %q", got.Body)
+	}
+}
+
+func TestScanCaseVariantDuplicateKeys(t *testing.T) {
+	type inner struct {
+		Key int `json:"key"`
+	}
+	type message struct {
+		Body    string            `json:"body"`
+		Headers map[string]string `json:"headers"`
+		Inner   inner             `json:"inner"`
+		List    []inner           `json:"list"`
+	}
+	typ := reflect.TypeOf(message{})
+
+	valid := []string{
+		`{"headers":{"A":"1","a":"2"}}`,
+		`{"Headers":{"A":"1","a":"2"}}`,
+		`{"unknown":{"A":"1","a":"2"}}`,
+	}
+	for _, payload := range valid {
+		if err := scanJSONInto([]byte(payload), typ, "json", 0, true); err != nil {
+			t.Errorf("Expected no error for %s, got: %v", payload, err)
+		}
+	}
+
+	invalid := []string{
+		`{"body":"safe","BODY":"evil"}`,
+		`{"inner":{"Key":1,"kEY":2}}`,
+		`{"list":[{"key":1,"KEY":2}]}`,
+	}
+	for _, payload := range invalid {
+		if err := scanJSONInto([]byte(payload), typ, "json", 0, true); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("Expected ErrDuplicateKey for %s, got: %v", payload, err)
+		}
+	}
+}
+
+func TestCallRejectDuplicateKeysCaseSensitiveMap(t *testing.T) {
+	var got map[string]int
+	c, _ := New(func(m map[string]int) { got = m })
+	c.RejectDuplicateKeys = true
+
+	if err := c.Call([]byte(`{"X":1,"x":2}`)); err != nil {
+		t.Fatalf("Expected case-distinct map keys to pass, got: %v", err)
+	}
+	if got["X"] != 1 || got["x"] != 2 {
+		t.Errorf("Expected both keys to be decoded, got %v", got)
+	}
+}
+
+func TestScanMaxDepth(t *testing.T) {
+	if err := scanJSON([]byte(`{"a":[{"b":1}]}`), 3, false); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if err := scanJSON([]byte(`{"a":1,"a":2}`), 3, false); err != nil {
+		t.Errorf("Expected duplicate keys to be allowed, got: %v", err)
+	}
+	if err := scanJSON([]byte(`{"a":[{"b":[1]}]}`), 3, false); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded, got: %v", err)
+	}
+}
+
+func TestCallMaxDepth(t *testing.T) {
+	var called bool
+	c, _ := New(func(_ interface{}) { called = true })
+	c.MaxDepth = 10
+
+	payload := strings.Repeat("[", 11) + strings.Repeat("]", 11)
+	if err := c.Call([]byte(payload)); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded, got: %v", err)
+	}
+	if called {
+		t.Error("Expected function not to be called")
+	}
+}
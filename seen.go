@@ -0,0 +1,49 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Seen is implemented by idempotency key stores. Check reports whether the
+// key was seen before and is expected to remember it otherwise. Keys are
+// checked before the function is called, so a store that only implements
+// Seen gives at-most-once processing: a payload the function fails on is
+// skipped when redelivered. Stores that also implement Forgetter make failed
+// payloads eligible for processing again.
+type Seen interface {
+	Check(key string) (bool, error)
+}
+
+// Forgetter is optionally implemented by Seen stores. Forget is called with
+// the key of a payload the function failed on, so that it is not treated as a
+// duplicate when redelivered.
+type Forgetter interface {
+	Forget(key string) error
+}
+
+// isDuplicate checks the idempotency key of the value with Seen. It returns
+// the key if it was recorded.
+func (c *Caller) isDuplicate(val reflect.Value) (key string, dup bool, err error) {
+	if c.IdempotencyKey == nil || c.Seen == nil {
+		return "", false, nil
+	}
+	if key = c.IdempotencyKey(val.Elem().Interface()); key == "" {
+		return "", false, nil
+	}
+	dup, err = c.Seen.Check(key)
+	return key, dup, err
+}
+
+// forget removes the key from Seen after a failed call, if the store
+// supports it.
+func (c *Caller) forget(key string, err error) error {
+	f, ok := c.Seen.(Forgetter)
+	if !ok || key == "" {
+		return err
+	}
+	if ferr := f.Forget(key); ferr != nil {
+		return errors.Join(err, ferr)
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package caller
+
+import (
+	"errors"
+	"testing"
+)
+
+type testSeen map[string]bool
+
+func (s testSeen) Check(key string) (bool, error) {
+	seen := s[key]
+	s[key] = true
+	return seen, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	c, _ := New(func(_ testMessage) { calls++ })
+	c.IdempotencyKey = func(v interface{}) string { return v.(testMessage).Body }
+	c.Seen = testSeen{}
+
+	for _, payload := range []string{testPayload, testPayload, `{"body":"Other"}`} {
+		if err := c.Call([]byte(payload)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected two calls, got %d", calls)
+	}
+}
+
+type testForgetfulSeen struct {
+	testSeen
+}
+
+func (s testForgetfulSeen) Forget(key string) error {
+	delete(s.testSeen, key)
+	return nil
+}
+
+func TestIdempotencyFailure(t *testing.T) {
+	errFailed := errors.New("failed")
+	var calls int
+	c, _ := New(func(_ testMessage) error {
+		calls++
+		return errFailed
+	})
+	c.IdempotencyKey = func(v interface{}) string { return v.(testMessage).Body }
+
+	// Plain stores give at-most-once processing
+	c.Seen = testSeen{}
+	if err := c.Call([]byte(testPayload)); err != errFailed {
+		t.Errorf("Expected function error, got: %v", err)
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Errorf("Expected redelivery to be skipped, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected one call, got %d", calls)
+	}
+
+	calls = 0
+	c.Seen = testForgetfulSeen{testSeen{}}
+	for i := 0; i < 2; i++ {
+		if err := c.Call([]byte(testPayload)); err != errFailed {
+			t.Errorf("Expected function error, got: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected failed payload to be processed again, got %d calls", calls)
+	}
+}
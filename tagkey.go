@@ -0,0 +1,131 @@
+package caller
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unmarshalTagged decodes JSON data into v just like json.Unmarshal does but
+// reads field names from the given struct tag key instead of `json`. Structs
+// are handled recursively, including pointers to and slices of structs. Any
+// other values, as well as types implementing json.Unmarshaler, are decoded
+// using the standard rules.
+func unmarshalTagged(data []byte, v interface{}, key string) error {
+	return decodeTagged(data, reflect.ValueOf(v).Elem(), key)
+}
+
+func decodeTagged(data []byte, val reflect.Value, key string) error {
+	if reflect.PtrTo(val.Type()).Implements(unmarshalerType) {
+		return json.Unmarshal(data, val.Addr().Interface())
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if isNull(data) {
+			val.Set(reflect.Zero(val.Type()))
+			return nil
+		}
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		return decodeTagged(data, val.Elem(), key)
+	case reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			val.Set(reflect.Zero(val.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(val.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeTagged(item, slice.Index(i), key); err != nil {
+				return err
+			}
+		}
+		val.Set(slice)
+		return nil
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		return decodeTaggedFields(fields, val, key)
+	}
+
+	return json.Unmarshal(data, val.Addr().Interface())
+}
+
+func decodeTaggedFields(fields map[string]json.RawMessage, val reflect.Value, key string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fval := val.Field(i)
+		name, ok := tagName(f, key)
+		if !ok {
+			continue
+		}
+		// Exported fields of embedded structs are promoted and remain
+		// settable even when the embedded type itself is unexported.
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			if err := decodeTaggedFields(fields, fval, key); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fval.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		raw, ok := lookupField(fields, name)
+		if !ok {
+			continue
+		}
+		if err := decodeTagged(raw, fval, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagName returns the field name from the given tag key, which is empty when
+// the tag has no name. Fields tagged with "-" are reported as skipped.
+func tagName(f reflect.StructField, key string) (name string, ok bool) {
+	tag := f.Tag.Get(key)
+	if tag == "-" {
+		return "", false
+	}
+	if i := strings.Index(tag, ","); i != -1 {
+		tag = tag[:i]
+	}
+	return tag, true
+}
+
+// lookupField finds a raw field value by its name, preferring an exact match
+// but accepting a case-insensitive one, just like encoding/json does.
+func lookupField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := fields[name]; ok {
+		return raw, true
+	}
+	for k, raw := range fields {
+		if strings.EqualFold(k, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
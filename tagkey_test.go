@@ -0,0 +1,65 @@
+package caller
+
+import (
+	"testing"
+)
+
+type testTaggedMessage struct {
+	Body   string            `msg:"body"`
+	Ignore string            `msg:"-"`
+	Nested *testTaggedNested `msg:"nested"`
+	Items  []testTaggedNested
+	testTaggedEmbedded
+}
+
+type testTaggedNested struct {
+	Value int `msg:"v"`
+}
+
+type testTaggedEmbedded struct {
+	Extra string `msg:"extra"`
+}
+
+func TestUnmarshalTagged(t *testing.T) {
+	payload := `{"body":"Success!","Ignore":"no","nested":{"v":1},"items":[{"v":2}],"extra":"yes"}`
+
+	var m testTaggedMessage
+	if err := unmarshalTagged([]byte(payload), &m, "msg"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if m.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", m.Body)
+	}
+	if m.Ignore != "" {
+		t.Errorf("Expected ignored field to be empty, got %q", m.Ignore)
+	}
+	if m.Nested == nil || m.Nested.Value != 1 {
+		t.Errorf("Expected nested value to be 1, got %+v", m.Nested)
+	}
+	if len(m.Items) != 1 || m.Items[0].Value != 2 {
+		t.Errorf("Expected one item with value 2, got %+v", m.Items)
+	}
+	if m.Extra != "yes" {
+		t.Errorf("Expected embedded field to be %q, got %q", "yes", m.Extra)
+	}
+}
+
+func TestUnmarshalTaggedFailure(t *testing.T) {
+	var m testTaggedMessage
+	if err := unmarshalTagged([]byte(`{"body":1}`), &m, "msg"); err == nil {
+		t.Error("Expected unmarshalling error, got nil")
+	}
+}
+
+func TestCallWithTagKey(t *testing.T) {
+	var got testTaggedMessage
+	c, _ := New(func(m testTaggedMessage) { got = m })
+	c.TagKey = "msg"
+
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Body != "Success!" {
+		t.Errorf("Expected body to be %q, got %q", "Success!", got.Body)
+	}
+}
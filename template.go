@@ -0,0 +1,138 @@
+package caller
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrInvalidTemplate is an error that is returned by Call when the Template
+// is not of the Caller function's argument type.
+var ErrInvalidTemplate = errors.New("template does not match function argument type")
+
+// applyTemplate copies the template into the decode target, which is a
+// pointer to the argument type.
+func (c *Caller) applyTemplate(val reflect.Value) error {
+	tpl := reflect.ValueOf(c.Template)
+	if tpl.Type() != c.argtyp {
+		return ErrInvalidTemplate
+	}
+
+	if c.DeepCopyTemplate {
+		deepCopy(val.Elem(), tpl)
+		return nil
+	}
+	// A shallow copy of a pointer would make the payload overwrite the
+	// template itself, so the value it points to is copied instead
+	if tpl.Kind() == reflect.Ptr && !tpl.IsNil() {
+		ptr := reflect.New(tpl.Type().Elem())
+		ptr.Elem().Set(tpl.Elem())
+		tpl = ptr
+	}
+	val.Elem().Set(tpl)
+	copyTopLevel(reflect.Indirect(val.Elem()))
+	return nil
+}
+
+// copyTopLevel replaces a map or slice value, or pointer, map and slice
+// fields of a struct, with copies one level deep, because the unmarshaller
+// decodes into them and would otherwise modify the template they are shared
+// with.
+func copyTopLevel(val reflect.Value) {
+	if val.Kind() != reflect.Struct {
+		copyOneLevel(val)
+		return
+	}
+	for i := 0; i < val.NumField(); i++ {
+		if f := val.Field(i); f.CanSet() {
+			copyOneLevel(f)
+		}
+	}
+}
+
+func copyOneLevel(val reflect.Value) {
+	if !val.IsValid() || !val.CanSet() {
+		return
+	}
+	switch val.Kind() {
+	case reflect.Ptr:
+		if !val.IsNil() {
+			ptr := reflect.New(val.Type().Elem())
+			ptr.Elem().Set(val.Elem())
+			val.Set(ptr)
+		}
+	case reflect.Map:
+		if !val.IsNil() {
+			m := reflect.MakeMapWithSize(val.Type(), val.Len())
+			iter := val.MapRange()
+			for iter.Next() {
+				m.SetMapIndex(iter.Key(), iter.Value())
+			}
+			val.Set(m)
+		}
+	case reflect.Slice:
+		if !val.IsNil() {
+			slice := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+			reflect.Copy(slice, val)
+			val.Set(slice)
+		}
+	}
+}
+
+// deepCopy recursively copies src into dst, which must be settable and of the
+// same type. Unexported struct fields are copied shallowly.
+func deepCopy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		ptr := reflect.New(src.Type().Elem())
+		deepCopy(ptr.Elem(), src.Elem())
+		dst.Set(ptr)
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		deepCopy(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		slice := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(slice.Index(i), src.Index(i))
+		}
+		dst.Set(slice)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(reflect.Zero(src.Type()))
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			elem := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(elem, iter.Value())
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		dst.Set(m)
+	default:
+		dst.Set(src)
+	}
+}
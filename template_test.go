@@ -0,0 +1,122 @@
+package caller
+
+import (
+	"testing"
+)
+
+type testConfig struct {
+	Name    string            `json:"name"`
+	Retries int               `json:"retries"`
+	Labels  map[string]string `json:"labels"`
+	Limits  *testLimits       `json:"limits"`
+}
+
+type testLimits struct {
+	Max int `json:"max"`
+}
+
+func newTestTemplate() testConfig {
+	return testConfig{
+		Name:    "default",
+		Retries: 3,
+		Labels:  map[string]string{"env": "prod"},
+		Limits:  &testLimits{Max: 10},
+	}
+}
+
+func TestCallWithTemplate(t *testing.T) {
+	var got testConfig
+	c, _ := New(func(cfg testConfig) { got = cfg })
+	c.Template = newTestTemplate()
+
+	if err := c.Call([]byte(`{"name":"custom"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Name != "custom" {
+		t.Errorf("Expected name to be overridden, got %q", got.Name)
+	}
+	if got.Retries != 3 {
+		t.Errorf("Expected retries to come from the template, got %d", got.Retries)
+	}
+}
+
+func TestCallWithDeepCopyTemplate(t *testing.T) {
+	var got testConfig
+	c, _ := New(func(cfg testConfig) { got = cfg })
+	tpl := newTestTemplate()
+	c.Template = tpl
+	c.DeepCopyTemplate = true
+
+	if err := c.Call([]byte(`{"labels":{"team":"core"},"limits":{"max":20}}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Labels["env"] != "prod" || got.Labels["team"] != "core" || got.Limits.Max != 20 {
+		t.Errorf("Expected payload to be merged into the template, got %+v", got)
+	}
+	if _, ok := tpl.Labels["team"]; ok || tpl.Limits.Max != 10 {
+		t.Errorf("Expected template to stay intact, got %+v", tpl)
+	}
+}
+
+func TestCallWithPointerTemplate(t *testing.T) {
+	var got *testConfig
+	c, _ := New(func(cfg *testConfig) { got = cfg })
+	tpl := newTestTemplate()
+	c.Template = &tpl
+
+	if err := c.Call([]byte(`{"name":"custom"}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Name != "custom" || got.Retries != 3 {
+		t.Errorf("Expected payload to be applied over the template, got %+v", got)
+	}
+	if tpl.Name != "default" {
+		t.Errorf("Expected template to stay intact, got %q", tpl.Name)
+	}
+}
+
+func TestCallWithInvalidTemplate(t *testing.T) {
+	c, _ := New(testFunSilent)
+	c.Template = testConfig{}
+
+	if err := c.Call([]byte(testPayload)); err != ErrInvalidTemplate {
+		t.Errorf("Expected ErrInvalidTemplate, got: %v", err)
+	}
+}
+
+func TestCallWithTemplateSharedFields(t *testing.T) {
+	var got testConfig
+	c, _ := New(func(cfg testConfig) { got = cfg })
+	tpl := newTestTemplate()
+	c.Template = tpl
+
+	if err := c.Call([]byte(`{"labels":{"team":"core"},"limits":{"max":20}}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Labels["env"] != "prod" || got.Labels["team"] != "core" || got.Limits.Max != 20 {
+		t.Errorf("Expected payload to be merged into the template, got %+v", got)
+	}
+	if _, ok := tpl.Labels["team"]; ok {
+		t.Errorf("Expected template labels to stay intact, got %v", tpl.Labels)
+	}
+	if tpl.Limits.Max != 10 {
+		t.Errorf("Expected template limits to stay intact, got %d", tpl.Limits.Max)
+	}
+}
+
+func TestCallWithMapTemplate(t *testing.T) {
+	var got map[string]int
+	c, _ := New(func(m map[string]int) { got = m })
+	tpl := map[string]int{"a": 1}
+	c.Template = tpl
+
+	if err := c.Call([]byte(`{"b":2}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got["a"] != 1 || got["b"] != 2 {
+		t.Errorf("Expected payload to be merged into the template, got %v", got)
+	}
+	if _, ok := tpl["b"]; ok {
+		t.Errorf("Expected template to stay intact, got %v", tpl)
+	}
+}
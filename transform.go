@@ -0,0 +1,116 @@
+package caller
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidTransform is an error that is returned by the New function when
+// the argument type has a `transform` tag that refers to an unknown
+// transform or is set on a field that is not a string.
+var ErrInvalidTransform = errors.New("invalid field transform")
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]func(string) string{
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}
+)
+
+// RegisterTransform makes a named string transform available to `transform`
+// struct tags. Transforms are resolved by New, so they should be registered
+// before creating Callers, typically in an init function. Built-in
+// transforms are trim, lower and upper.
+func RegisterTransform(name string, fn func(string) string) {
+	transformsMu.Lock()
+	transforms[name] = fn
+	transformsMu.Unlock()
+}
+
+// fieldTransform describes a chain of transforms applied to a string field
+// after unmarshalling, which are configured with a tag like
+// `transform:"trim,lower"`.
+type fieldTransform struct {
+	index []int
+	fns   []func(string) string
+}
+
+func collectTransforms(typ reflect.Type) ([]fieldTransform, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	return collectStructTransforms(typ, nil)
+}
+
+func collectStructTransforms(typ reflect.Type, index []int) ([]fieldTransform, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var res []fieldTransform
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+
+		if f.Type.Kind() == reflect.Struct {
+			nested, err := collectStructTransforms(f.Type, idx)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, nested...)
+			continue
+		}
+
+		tag := f.Tag.Get("transform")
+		if tag == "" || f.PkgPath != "" {
+			continue
+		}
+		if f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: field %s is not a string", ErrInvalidTransform, f.Name)
+		}
+		ft := fieldTransform{index: idx}
+		for _, name := range strings.Split(tag, ",") {
+			fn, ok := transforms[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown transform %q on field %s", ErrInvalidTransform, name, f.Name)
+			}
+			ft.fns = append(ft.fns, fn)
+		}
+		res = append(res, ft)
+	}
+	return res, nil
+}
+
+// applyTransforms runs field transforms on the decoded value, which is a
+// pointer to the argument type.
+func applyTransforms(val reflect.Value, fts []fieldTransform) {
+	if len(fts) == 0 {
+		return
+	}
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	for _, ft := range fts {
+		fval := val.FieldByIndex(ft.index)
+		str := fval.String()
+		for _, fn := range ft.fns {
+			str = fn(str)
+		}
+		fval.SetString(str)
+	}
+}
@@ -0,0 +1,96 @@
+package caller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testTransformMessage struct {
+	Email string `json:"email" transform:"trim,lower"`
+	Name  string `json:"name" transform:"trim"`
+	Inner struct {
+		Code string `json:"code" transform:"upper"`
+	} `json:"inner"`
+}
+
+func TestCallWithTransforms(t *testing.T) {
+	var got testTransformMessage
+	c, err := New(func(m testTransformMessage) { got = m })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	payload := `{"email":"  Bob@Example.COM ","name":"  Bob  ","inner":{"code":"abc"}}`
+	if err := c.Call([]byte(payload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Email != "bob@example.com" {
+		t.Errorf("Expected email to be %q, got %q", "bob@example.com", got.Email)
+	}
+	if got.Name != "Bob" {
+		t.Errorf("Expected name to be %q, got %q", "Bob", got.Name)
+	}
+	if got.Inner.Code != "ABC" {
+		t.Errorf("Expected code to be %q, got %q", "ABC", got.Inner.Code)
+	}
+}
+
+func TestCallWithPointerTransforms(t *testing.T) {
+	var got *testTransformMessage
+	c, _ := New(func(m *testTransformMessage) { got = m })
+
+	if err := c.Call([]byte(`{"name":" Bob "}`)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Name != "Bob" {
+		t.Errorf("Expected name to be %q, got %q", "Bob", got.Name)
+	}
+	if err := c.Call([]byte(`null`)); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestRegisterTransform(t *testing.T) {
+	RegisterTransform("reverse", func(s string) string {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	})
+
+	var got string
+	c, err := New(func(m struct {
+		Body string `json:"body" transform:"reverse,upper"`
+	}) {
+		got = m.Body
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := c.Call([]byte(testPayload)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != "!SSECCUS" {
+		t.Errorf("Expected body to be %q, got %q", "!SSECCUS", got)
+	}
+}
+
+func TestNewCallerWithInvalidTransform(t *testing.T) {
+	_, err := New(func(_ struct {
+		Body string `transform:"unknown"`
+	}) {
+	})
+	if !errors.Is(err, ErrInvalidTransform) || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("Expected ErrInvalidTransform for unknown transform, got: %v", err)
+	}
+
+	_, err = New(func(_ struct {
+		Count int `transform:"trim"`
+	}) {
+	})
+	if !errors.Is(err, ErrInvalidTransform) {
+		t.Errorf("Expected ErrInvalidTransform for non-string field, got: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package caller
+
+import (
+	"reflect"
+)
+
+// typeInfo holds field metadata of an argument type that is needed by the
+// reflection based passes. It is computed by New and copied onto the Caller.
+type typeInfo struct {
+	transforms []fieldTransform
+	required   []requiredField
+	token      []int
+	times      []reflect.StructField
+	alloc      [][]int
+	aliased    []reflect.StructField
+	err        error
+}
+
+// newTypeInfo collects field metadata of the argument type.
+func newTypeInfo(typ reflect.Type) *typeInfo {
+	info := &typeInfo{
+		required: collectRequired(typ),
+		token:    findCancelToken(typ),
+		times:    collectTimeFields(typ),
+		alloc:    collectAllocFields(typ),
+		aliased:  collectAliased(typ),
+	}
+	info.transforms, info.err = collectTransforms(typ)
+	return info
+}
@@ -0,0 +1,10 @@
+module github.com/localhots/caller/ws
+
+go 1.21
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/localhots/caller v0.0.0
+)
+
+replace github.com/localhots/caller => ../
@@ -0,0 +1,28 @@
+// Package ws drives callers with messages received over a WebSocket
+// connection.
+package ws
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/localhots/caller"
+)
+
+// Serve reads messages from the connection in a loop and calls the Caller
+// with each text or binary message payload. It returns when reading from the
+// connection fails, which includes the connection being closed by the peer.
+// Errors returned by the Caller do not stop the loop, they are passed to the
+// onError callback instead, which may be nil to ignore them.
+func Serve(conn *websocket.Conn, c *caller.Caller, onError func(err error)) error {
+	for {
+		typ, payload, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if typ != websocket.TextMessage && typ != websocket.BinaryMessage {
+			continue
+		}
+		if err := c.Call(payload); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
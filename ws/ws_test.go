@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/localhots/caller"
+)
+
+type testMessage struct {
+	Body string `json:"body"`
+}
+
+func TestServe(t *testing.T) {
+	var bodies []string
+	var errs []error
+	c, _ := caller.New(func(m testMessage) { bodies = append(bodies, m.Body) })
+
+	done := make(chan error, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		done <- Serve(conn, c, func(err error) { errs = append(errs, err) })
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"body":"first"}`))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{`))
+	conn.WriteMessage(websocket.BinaryMessage, []byte(`{"body":"second"}`))
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+
+	if err := <-done; !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected normal closure error, got: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != "first" || bodies[1] != "second" {
+		t.Errorf("Expected two calls with bodies first, second, got %q", bodies)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected one handler error, got %d", len(errs))
+	}
+}